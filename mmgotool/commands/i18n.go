@@ -9,13 +9,13 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
-	"io/ioutil"
+	"go/types"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -45,16 +45,17 @@ var ExtractCmd = &cobra.Command{
 	Use:     "extract",
 	Short:   "Extract translations",
 	Long:    "Extract translations from the source code and put them into the i18n/en.json file",
-	Example: "  i18n extract",
+	Example: "  i18n extract\n  i18n extract --diff\n  i18n extract --output -",
 	RunE:    extractCmdF,
 }
 
 var CheckCmd = &cobra.Command{
-	Use:     "check",
-	Short:   "Check translations",
-	Long:    "Check translations existing in the source code and compare it to the i18n/en.json file",
-	Example: "  i18n check",
-	RunE:    checkCmdF,
+	Use:   "check",
+	Short: "Check translations",
+	Long:  "Check translations existing in the source code and compare it to the i18n/en.json file",
+	Example: `  i18n check
+  i18n check --since origin/master`,
+	RunE: checkCmdF,
 }
 
 var CheckEmptySrcCmd = &cobra.Command{
@@ -74,26 +75,23 @@ var CleanEmptyCmd = &cobra.Command{
 }
 
 func init() {
-	ExtractCmd.Flags().Bool("skip-dynamic", false, "Whether to skip dynamically added translations")
-	ExtractCmd.Flags().String("portal-dir", "../customer-web-server", "Path to folder with the Mattermost Customer Portal source code")
-	ExtractCmd.Flags().String("enterprise-dir", "../enterprise", "Path to folder with the Mattermost enterprise source code")
-	ExtractCmd.Flags().String("mattermost-dir", "./", "Path to folder with the Mattermost source code")
+	addExtractFlags(ExtractCmd)
 	ExtractCmd.Flags().Bool("contributor", false, "Allows contributors safely extract translations from source code without removing enterprise messages keys")
+	ExtractCmd.Flags().String("output", "", "Path to write the extracted translations to instead of i18n/en.json, - for standard output")
+	ExtractCmd.Flags().Bool("diff", false, "Print a unified diff of the changes to i18n/en.json instead of applying them")
 
-	CheckCmd.Flags().Bool("skip-dynamic", false, "Whether to skip dynamically added translations")
-	CheckCmd.Flags().String("portal-dir", "../customer-web-server", "Path to folder with the Mattermost Customer Portal source code")
-	CheckCmd.Flags().String("enterprise-dir", "../enterprise", "Path to folder with the Mattermost enterprise source code")
-	CheckCmd.Flags().String("mattermost-dir", "./", "Path to folder with the Mattermost source code")
+	addExtractFlags(CheckCmd)
+	CheckCmd.Flags().Bool("strict", false, "Fail on translation keys not known at compile time unless annotated with \"// "+dynamicCallAnnotation+"\"")
+	CheckCmd.Flags().String("since", "", "Git ref to compare with, reporting only the keys added or removed since then and the unrelated changes to i18n/en.json")
+	addFormatFlag(CheckCmd)
 
-	CheckEmptySrcCmd.Flags().String("portal-dir", "../customer-web-server", "Path to folder with the Mattermost Customer Portal source code")
-	CheckEmptySrcCmd.Flags().String("enterprise-dir", "../enterprise", "Path to folder with the Mattermost enterprise source code")
-	CheckEmptySrcCmd.Flags().String("mattermost-dir", "./", "Path to folder with the Mattermost source code")
+	addLocaleDirFlags(CheckEmptySrcCmd)
+	addFormatFlag(CheckEmptySrcCmd)
 
 	CleanEmptyCmd.Flags().Bool("dry-run", false, "Run without applying changes")
 	CleanEmptyCmd.Flags().Bool("check", false, "Throw exit code on empty translation strings")
-	CleanEmptyCmd.Flags().String("portal-dir", "../customer-web-server", "Path to folder with the Mattermost Customer Portal source code")
-	CleanEmptyCmd.Flags().String("enterprise-dir", "../enterprise", "Path to folder with the Mattermost enterprise source code")
-	CleanEmptyCmd.Flags().String("mattermost-dir", "./", "Path to folder with the Mattermost source code")
+	addLocaleDirFlags(CleanEmptyCmd)
+	addFormatFlag(CleanEmptyCmd)
 
 	I18nCmd.AddCommand(
 		ExtractCmd,
@@ -105,74 +103,246 @@ func init() {
 }
 
 func getBaseFileSrcStrings(mattermostDir string) ([]Translation, error) {
-	jsonFile, err := ioutil.ReadFile(path.Join(mattermostDir, "i18n", "en.json"))
-	if err != nil {
-		return nil, err
-	}
-	var translations []Translation
-	_ = json.Unmarshal(jsonFile, &translations)
-	return translations, nil
+	return loadTranslations(path.Join(mattermostDir, "i18n", baseLocaleFile))
 }
 
-func extractSrcStrings(enterpriseDir, mattermostDir, portalDir string) map[string]bool {
-	i18nStrings := map[string]bool{}
-	walkFunc := func(p string, info os.FileInfo, err error) error {
-		if strings.HasPrefix(p, path.Join(mattermostDir, "vendor")) {
-			return nil
-		}
-		return extractFromPath(p, info, err, i18nStrings)
-	}
-	if portalDir != "" {
-		_ = filepath.Walk(portalDir, walkFunc)
-	} else {
-		_ = filepath.Walk(mattermostDir, walkFunc)
-		_ = filepath.Walk(enterpriseDir, walkFunc)
+// extractOptions holds the parameters shared by the commands scanning the
+// source code for translation keys.
+type extractOptions struct {
+	EnterpriseDir  string
+	MattermostDir  string
+	PortalDir      string
+	TranslationDir string
+	SkipDynamic    bool
+	TypeAware      bool
+	Config         *i18nConfig
+	// Jobs is the number of files scanned concurrently, one per CPU when
+	// not positive.
+	Jobs int
+	// CacheDir holds the cache of scanned files, disabled when empty.
+	CacheDir string
+	// BuildTags are the build tags considered satisfied on top of those of
+	// every known platform, or of the host platform when type-aware.
+	BuildTags []string
+}
+
+// sourceDirs returns the folders holding the source code to scan.
+func (o *extractOptions) sourceDirs() []string {
+	if o.PortalDir != "" {
+		return []string{o.PortalDir}
 	}
-	return i18nStrings
+	return []string{o.MattermostDir, o.EnterpriseDir}
 }
 
-func extractCmdF(command *cobra.Command, args []string) error {
+func addExtractFlags(command *cobra.Command) {
+	command.Flags().Bool("skip-dynamic", false, "Whether to skip dynamically added translations")
+	command.Flags().String("portal-dir", "../customer-web-server", "Path to folder with the Mattermost Customer Portal source code")
+	command.Flags().String("enterprise-dir", "../enterprise", "Path to folder with the Mattermost enterprise source code")
+	command.Flags().String("mattermost-dir", "./", "Path to folder with the Mattermost source code")
+	command.Flags().String("config", "", "Path to the i18n configuration file (defaults to "+i18nConfigFileName+" in the source code folder)")
+	command.Flags().Bool("type-aware", false, "Load the Go packages and only accept calls resolving to a translation function")
+	command.Flags().Int("jobs", runtime.NumCPU(), "Number of source files scanned concurrently")
+	command.Flags().String("cache-dir", defaultScanCacheDir(), "Path to the folder caching the scanned source files")
+	command.Flags().Bool("no-cache", false, "Scan every source file without using the cache")
+	command.Flags().StringSlice("tags", nil, "Build tags considered satisfied when selecting the source files to scan")
+}
+
+func getExtractOptions(command *cobra.Command) (*extractOptions, error) {
 	skipDynamic, err := command.Flags().GetBool("skip-dynamic")
 	if err != nil {
-		return errors.New("invalid skip-dynamic parameter")
+		return nil, errors.New("invalid skip-dynamic parameter")
 	}
 	enterpriseDir, err := command.Flags().GetString("enterprise-dir")
 	if err != nil {
-		return errors.New("invalid enterprise-dir parameter")
+		return nil, errors.New("invalid enterprise-dir parameter")
 	}
 	mattermostDir, err := command.Flags().GetString("mattermost-dir")
 	if err != nil {
-		return errors.New("invalid mattermost-dir parameter")
-	}
-	contributorMode, err := command.Flags().GetBool("contributor")
-	if err != nil {
-		return errors.New("invalid contributor parameter")
+		return nil, errors.New("invalid mattermost-dir parameter")
 	}
 	portalDir, err := command.Flags().GetString("portal-dir")
 	if err != nil {
-		return errors.New("invalid portal-dir parameter")
+		return nil, errors.New("invalid portal-dir parameter")
 	}
 	translationDir := mattermostDir
 	if portalDir != "" {
 		if enterpriseDir != "" || mattermostDir != "" {
-			return errors.New("please specify EITHER portal-dir or enterprise-dir/mattermost-dir")
+			return nil, errors.New("please specify EITHER portal-dir or enterprise-dir/mattermost-dir")
 		}
 		skipDynamic = true // dynamics are not needed for portal
 		translationDir = portalDir
 	}
-	i18nStrings := extractSrcStrings(enterpriseDir, mattermostDir, portalDir)
-	if !skipDynamic {
-		addDynamicallyGeneratedStrings(i18nStrings)
+	typeAware, err := command.Flags().GetBool("type-aware")
+	if err != nil {
+		return nil, errors.New("invalid type-aware parameter")
 	}
-	// Delete any untranslated keys
-	delete(i18nStrings, untranslatedKey)
-	var i18nStringsList []string
-	for id := range i18nStrings {
-		i18nStringsList = append(i18nStringsList, id)
+	configPath, err := command.Flags().GetString("config")
+	if err != nil {
+		return nil, errors.New("invalid config parameter")
 	}
-	sort.Strings(i18nStringsList)
+	config, err := loadI18nConfig(translationDir, configPath)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := command.Flags().GetInt("jobs")
+	if err != nil {
+		return nil, errors.New("invalid jobs parameter")
+	}
+	cacheDir, err := command.Flags().GetString("cache-dir")
+	if err != nil {
+		return nil, errors.New("invalid cache-dir parameter")
+	}
+	noCache, err := command.Flags().GetBool("no-cache")
+	if err != nil {
+		return nil, errors.New("invalid no-cache parameter")
+	}
+	if noCache {
+		cacheDir = ""
+	}
+	buildTags, err := command.Flags().GetStringSlice("tags")
+	if err != nil {
+		return nil, errors.New("invalid tags parameter")
+	}
+	return &extractOptions{
+		EnterpriseDir:  enterpriseDir,
+		MattermostDir:  mattermostDir,
+		PortalDir:      portalDir,
+		TranslationDir: translationDir,
+		SkipDynamic:    skipDynamic,
+		TypeAware:      typeAware,
+		Config:         config,
+		Jobs:           jobs,
+		CacheDir:       cacheDir,
+		BuildTags:      buildTags,
+	}, nil
+}
+
+// extractResult is the outcome of scanning the source code for translation
+// keys.
+type extractResult struct {
+	Usages i18nUsages
+	// DynamicCalls are the calls to translation functions whose key is not
+	// known until runtime.
+	DynamicCalls []dynamicCall
+	// RejectedCalls are the calls matching a translation function by name
+	// that type-aware extraction rejected.
+	RejectedCalls []rejectedCall
+	// Calls are the call sites whose template parameters are known.
+	Calls []translationCall
+}
+
+// translationCall is a call site of a translation function using a known
+// key, along with the names of the template parameters it passes.
+type translationCall struct {
+	keyLocation
+	ID string
+	// Func is the called function expression and Package the name of the
+	// package of the call site.
+	Func    string
+	Package string
+	// Params are the template parameters passed to the call, only set when
+	// KnownParams is true.
+	Params      map[string]bool
+	KnownParams bool
+}
 
-	sourceStrings, err := getBaseFileSrcStrings(translationDir)
+// addCall records the key of a call to fn from package pkg, or the call
+// itself when its key is only known at runtime and the call is not annotated.
+// eval resolves the string value of constant expressions.
+func (r *extractResult) addCall(call *ast.CallExpr, fn *translationFunc, pos token.Position, pkg string, annotated bool, eval func(ast.Expr) (string, bool)) {
+	arg := fn.keyArg(call)
+	id, ok := eval(arg)
+	if !ok {
+		if !annotated {
+			r.DynamicCalls = append(r.DynamicCalls, newDynamicCall(pos, call, arg))
+		}
+		return
+	}
+	params, knownParams := fn.params(call, eval)
+	r.addKey(translationCall{
+		keyLocation: keyLocation{File: pos.Filename, Line: pos.Line},
+		ID:          id,
+		Func:        types.ExprString(call.Fun),
+		Package:     pkg,
+		Params:      params,
+		KnownParams: knownParams,
+	})
+}
+
+// addKey records a call using a known translation key.
+func (r *extractResult) addKey(call translationCall) {
+	r.Usages.add(call.ID, token.Position{Filename: call.File, Line: call.Line})
+	r.Calls = append(r.Calls, call)
+}
+
+func extractSrcStrings(opts *extractOptions) (*extractResult, error) {
+	if opts.TypeAware {
+		return extractTypedSrcStrings(opts)
+	}
+	e := newExtractor(opts.Config)
+	for _, dir := range opts.sourceDirs() {
+		if err := e.walkDir(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var cache *scanCache
+	if opts.CacheDir != "" {
+		cache = loadScanCache(opts.CacheDir, opts.Config.Functions)
+	}
+	e.scan(opts.Jobs, newBuildTags(opts.BuildTags), cache)
+	if err := cache.save(); err != nil {
+		log.Printf("Unable to save the extraction cache: %v\n", err)
+	}
+	e.resolveCalls()
+	if len(e.errors) > 0 {
+		return e.result, e.errors
+	}
+	return e.result, nil
+}
+
+// extractUsages scans the source code and, unless disabled, adds the
+// dynamically generated keys matching baseFileList. The loaded dynamic keys
+// are returned so callers can report stale entries.
+func extractUsages(opts *extractOptions, baseFileList []string) (*extractResult, []*dynamicKey, error) {
+	result, err := extractSrcStrings(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var dynamicKeys []*dynamicKey
+	if !opts.SkipDynamic {
+		dynamicKeys, err = loadDynamicKeys(opts.TranslationDir, opts.Config)
+		if err != nil {
+			return nil, nil, err
+		}
+		addDynamicallyGeneratedStrings(result.Usages, dynamicKeys, baseFileList)
+	}
+	// Delete any untranslated keys
+	delete(result.Usages, untranslatedKey)
+	return result, dynamicKeys, nil
+}
+
+func extractCmdF(command *cobra.Command, args []string) error {
+	opts, err := getExtractOptions(command)
+	if err != nil {
+		return err
+	}
+	contributorMode, err := command.Flags().GetBool("contributor")
+	if err != nil {
+		return errors.New("invalid contributor parameter")
+	}
+	output, err := command.Flags().GetString("output")
+	if err != nil {
+		return errors.New("invalid output parameter")
+	}
+	diff, err := command.Flags().GetBool("diff")
+	if err != nil {
+		return errors.New("invalid diff parameter")
+	}
+	if diff && output != "" {
+		return errors.New("please specify EITHER output or diff")
+	}
+	sourceStrings, err := getBaseFileSrcStrings(opts.TranslationDir)
 	if err != nil {
 		return err
 	}
@@ -187,6 +357,14 @@ func extractCmdF(command *cobra.Command, args []string) error {
 	}
 	sort.Strings(baseFileList)
 
+	extracted, _, err := extractUsages(opts, baseFileList)
+	if err != nil {
+		command.SilenceUsage = true
+		return fmt.Errorf("%v\n%s left unchanged", err, path.Join(opts.MattermostDir, "i18n", baseLocaleFile))
+	}
+	i18nStrings := extracted.Usages
+	i18nStringsList := i18nStrings.keys()
+
 	for _, translationKey := range i18nStringsList {
 		if _, hasKey := idx[translationKey]; !hasKey {
 			resultMap[translationKey] = Translation{Id: translationKey, Translation: ""}
@@ -202,67 +380,41 @@ func extractCmdF(command *cobra.Command, args []string) error {
 		}
 	}
 
-	var result []Translation
+	var result []Item
 	for _, t := range resultMap {
-		result = append(result, t)
+		translation, err := marshalTranslation(t.Translation)
+		if err != nil {
+			return err
+		}
+		result = append(result, Item{ID: t.Id, Translation: translation})
 	}
-	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
-
-	f, err := os.Create(path.Join(mattermostDir, "i18n", "en.json"))
+	baseFile := path.Join(opts.MattermostDir, "i18n", baseLocaleFile)
+	data, err := formatLocaleFile(result)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	encoder := json.NewEncoder(f)
-	encoder.SetIndent("", "  ")
-	encoder.SetEscapeHTML(false)
-	err = encoder.Encode(result)
-	if err != nil {
+	switch {
+	case diff:
+		return writeLocaleDiff(os.Stdout, baseFile, data)
+	case output == "-":
+		_, err = os.Stdout.Write(data)
 		return err
+	case output != "":
+		return writeFileAtomically(output, data, 0644)
 	}
-
-	return nil
+	return writeFileAtomically(baseFile, data, 0644)
 }
 
 func checkCmdF(command *cobra.Command, args []string) error {
-	skipDynamic, err := command.Flags().GetBool("skip-dynamic")
-	if err != nil {
-		return errors.New("invalid skip-dynamic parameter")
-	}
-	enterpriseDir, err := command.Flags().GetString("enterprise-dir")
-	if err != nil {
-		return errors.New("invalid enterprise-dir parameter")
-	}
-	mattermostDir, err := command.Flags().GetString("mattermost-dir")
+	r, err := newReporter(command)
 	if err != nil {
-		return errors.New("invalid mattermost-dir parameter")
+		return err
 	}
-	portalDir, err := command.Flags().GetString("portal-dir")
+	opts, err := getExtractOptions(command)
 	if err != nil {
-		return errors.New("invalid portal-dir parameter")
-	}
-	translationDir := mattermostDir
-	if portalDir != "" {
-		if enterpriseDir != "" || mattermostDir != "" {
-			return errors.New("please specify EITHER portal-dir or enterprise-dir/mattermost-dir")
-		}
-		translationDir = portalDir
-		skipDynamic = true // dynamics are not needed for portal
-	}
-	extractedSrcStrings := extractSrcStrings(enterpriseDir, mattermostDir, portalDir)
-	if !skipDynamic {
-		addDynamicallyGeneratedStrings(extractedSrcStrings)
-	}
-	// Delete any untranslated keys
-	delete(extractedSrcStrings, untranslatedKey)
-	var extractedList []string
-	for id := range extractedSrcStrings {
-		extractedList = append(extractedList, id)
+		return err
 	}
-	sort.Strings(extractedList)
-
-	srcStrings, err := getBaseFileSrcStrings(translationDir)
+	srcStrings, err := getBaseFileSrcStrings(opts.TranslationDir)
 	if err != nil {
 		return err
 	}
@@ -275,304 +427,273 @@ func checkCmdF(command *cobra.Command, args []string) error {
 	}
 	sort.Strings(baseFileList)
 
-	changed := false
-	for _, translationKey := range extractedList {
-		if _, hasKey := idx[translationKey]; !hasKey {
-			fmt.Println("Added:", translationKey)
-			changed = true
-		}
+	strict, err := command.Flags().GetBool("strict")
+	if err != nil {
+		return errors.New("invalid strict parameter")
 	}
 
-	for _, translationKey := range baseFileList {
-		if _, hasKey := extractedSrcStrings[translationKey]; !hasKey {
-			fmt.Println("Removed:", translationKey)
-			changed = true
-		}
+	since, err := command.Flags().GetString("since")
+	if err != nil {
+		return errors.New("invalid since parameter")
 	}
-	if changed {
+
+	extracted, dynamicKeys, err := extractUsages(opts, baseFileList)
+	if err != nil {
 		command.SilenceUsage = true
-		return errors.New("translation source strings file out of date")
+		return err
 	}
-	return nil
-}
+	extractedSrcStrings := extracted.Usages
+	extractedList := extractedSrcStrings.keys()
 
-func addDynamicallyGeneratedStrings(i18nStrings map[string]bool) {
-	i18nStrings["model.user.is_valid.pwd.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_lowercase.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_lowercase_number.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_lowercase_number_symbol.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_lowercase_symbol.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_lowercase_uppercase.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_lowercase_uppercase_number.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_lowercase_uppercase_number_symbol.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_lowercase_uppercase_symbol.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_number.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_number_symbol.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_symbol.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_uppercase.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_uppercase_number.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_uppercase_number_symbol.app_error"] = true
-	i18nStrings["model.user.is_valid.pwd_uppercase_symbol.app_error"] = true
-	i18nStrings["model.user.is_valid.id.app_error"] = true
-	i18nStrings["model.user.is_valid.create_at.app_error"] = true
-	i18nStrings["model.user.is_valid.update_at.app_error"] = true
-	i18nStrings["model.user.is_valid.username.app_error"] = true
-	i18nStrings["model.user.is_valid.email.app_error"] = true
-	i18nStrings["model.user.is_valid.nickname.app_error"] = true
-	i18nStrings["model.user.is_valid.position.app_error"] = true
-	i18nStrings["model.user.is_valid.first_name.app_error"] = true
-	i18nStrings["model.user.is_valid.last_name.app_error"] = true
-	i18nStrings["model.user.is_valid.auth_data.app_error"] = true
-	i18nStrings["model.user.is_valid.auth_data_type.app_error"] = true
-	i18nStrings["model.user.is_valid.auth_data_pwd.app_error"] = true
-	i18nStrings["model.user.is_valid.password_limit.app_error"] = true
-	i18nStrings["model.user.is_valid.locale.app_error"] = true
-	i18nStrings["January"] = true
-	i18nStrings["February"] = true
-	i18nStrings["March"] = true
-	i18nStrings["April"] = true
-	i18nStrings["May"] = true
-	i18nStrings["June"] = true
-	i18nStrings["July"] = true
-	i18nStrings["August"] = true
-	i18nStrings["September"] = true
-	i18nStrings["October"] = true
-	i18nStrings["November"] = true
-	i18nStrings["December"] = true
-}
-
-func extractByFuncName(name string, args []ast.Expr) *string {
-	if name == "T" {
-		if len(args) == 0 {
-			return nil
+	// Without a base, everything is reported as if the whole source code
+	// was new.
+	base := &checkBase{}
+	root := opts.MattermostDir
+	if opts.PortalDir != "" {
+		root = opts.PortalDir
+	}
+	if since != "" {
+		if base, err = loadCheckBase(opts, since); err != nil {
+			command.SilenceUsage = true
+			return err
 		}
+	}
 
-		key, ok := args[0].(*ast.BasicLit)
-		if !ok {
-			return nil
-		}
-		return &key.Value
-	} else if name == "NewAppError" {
-		if len(args) < 2 {
-			return nil
+	changed := false
+	for _, key := range unmatchedDynamicKeys(dynamicKeys, baseFileList) {
+		if base.UnmatchedDynamicKeys[key.Entry] {
+			continue
 		}
+		r.report(fmt.Sprintf("Unmatched dynamic key: %s (%s:%d)", key.Entry, key.Source, key.Line), finding{
+			RuleID:   "unmatched-dynamic-key",
+			Severity: severityError,
+			Key:      key.Entry,
+			Message:  "dynamic key entry matches no translation key",
+		}.at(keyLocation{File: key.Source, Line: key.Line}))
+		changed = true
+	}
 
-		key, ok := args[1].(*ast.BasicLit)
-		if !ok {
-			return nil
-		}
-		return &key.Value
-	} else if name == "newAppError" {
-		if len(args) < 1 {
-			return nil
-		}
-		key, ok := args[0].(*ast.BasicLit)
-		if !ok {
-			return nil
-		}
-		return &key.Value
-	} else if name == "NewUserFacingError" {
-		if len(args) < 1 {
-			return nil
-		}
-		key, ok := args[0].(*ast.BasicLit)
-		if !ok {
-			return nil
-		}
-		return &key.Value
-	} else if name == "translateFunc" {
-		if len(args) < 1 {
-			return nil
+	for _, translationKey := range extractedList {
+		if _, hasKey := idx[translationKey]; !hasKey && !base.missingKey(translationKey) {
+			text := []string{"Added: " + translationKey}
+			var findings []finding
+			for _, location := range extractedSrcStrings[translationKey] {
+				text = append(text, "\t"+location.String())
+				findings = append(findings, finding{
+					RuleID:   "added-key",
+					Severity: severityError,
+					Key:      translationKey,
+					Message:  "translation key missing from " + baseLocaleFile,
+				}.at(location))
+			}
+			r.report(strings.Join(text, "\n"), findings...)
+			changed = true
 		}
+	}
 
-		key, ok := args[0].(*ast.BasicLit)
-		if !ok {
-			return nil
-		}
-		return &key.Value
-	} else if name == "TranslateAsHTML" || name == "TranslateAsHtml" {
-		if len(args) < 2 {
-			return nil
+	baseFile := path.Join(opts.TranslationDir, "i18n", baseLocaleFile)
+	for _, translationKey := range baseFileList {
+		if _, hasKey := extractedSrcStrings[translationKey]; !hasKey && !base.unusedKey(translationKey) {
+			r.report("Removed: "+translationKey, finding{
+				RuleID:   "removed-key",
+				Severity: severityError,
+				Key:      translationKey,
+				File:     baseFile,
+				Line:     r.keyLine(baseFile, translationKey),
+				Message:  "translation key not used in the source code",
+			})
+			changed = true
 		}
+	}
 
-		key, ok := args[1].(*ast.BasicLit)
-		if !ok {
-			return nil
-		}
-		return &key.Value
-	} else if name == "userLocale" {
-		if len(args) < 1 {
-			return nil
+	if since != "" {
+		reportUnrelatedSourceChanges(r, base, idx, extractedSrcStrings, baseFile)
+	}
+
+	for _, call := range extracted.RejectedCalls {
+		if base.Calls[callID(root, call.File, call.Func, call.Reason)] {
+			continue
 		}
+		r.report("Rejected by type: "+call.String(), finding{
+			RuleID:   "rejected-call",
+			Severity: severityNote,
+			Message:  fmt.Sprintf("call to %s rejected: %s", call.Func, call.Reason),
+		}.at(call.keyLocation))
+	}
 
-		key, ok := args[0].(*ast.BasicLit)
-		if !ok {
-			return nil
+	dynamicCalls := 0
+	for _, call := range extracted.DynamicCalls {
+		if base.Calls[callID(root, call.File, call.Func, call.Key)] {
+			continue
 		}
-		return &key.Value
-	} else if name == "localT" {
-		if len(args) < 1 {
-			return nil
+		dynamicCalls++
+		text := "Warning: dynamic key: " + call.String()
+		severity := severityWarning
+		if strict {
+			text = "Dynamic key: " + call.String()
+			severity = severityError
 		}
+		r.report(text, finding{
+			RuleID:   "dynamic-key",
+			Severity: severity,
+			Message:  fmt.Sprintf("translation key of %s(%s) not known at compile time", call.Func, call.Key),
+		}.at(call.keyLocation))
+	}
 
-		key, ok := args[0].(*ast.BasicLit)
-		if !ok {
-			return nil
-		}
-		return &key.Value
+	if err = r.flush(); err != nil {
+		return err
+	}
+	if changed {
+		command.SilenceUsage = true
+		return errors.New("translation source strings file out of date")
+	}
+	if strict && dynamicCalls > 0 {
+		command.SilenceUsage = true
+		return errors.New("translation keys not known at compile time found")
 	}
 	return nil
 }
 
-func extractForConstants(name string, valueNode ast.Expr) *string {
-	validConstants := map[string]bool{
-		"MISSING_CHANNEL_ERROR":        true,
-		"MISSING_CHANNEL_MEMBER_ERROR": true,
-		"CHANNEL_EXISTS_ERROR":         true,
-		"MISSING_STATUS_ERROR":         true,
-		"TEAM_MEMBER_EXISTS_ERROR":     true,
-		"MISSING_AUTH_ACCOUNT_ERROR":   true,
-		"MISSING_ACCOUNT_ERROR":        true,
-		"EXPIRED_LICENSE_ERROR":        true,
-		"INVALID_LICENSE_ERROR":        true,
-		"MissingChannelError":          true,
-		"MissingChannelMemberError":    true,
-		"ChannelExistsError":           true,
-		"MissingStatusError":           true,
-		"TeamMemberExistsError":        true,
-		"MissingAuthAccountError":      true,
-		"MissingAccountError":          true,
-		"ExpiredLicenseError":          true,
-		"InvalidLicenseError":          true,
-		"NoTranslation":                true,
-	}
-
-	if _, ok := validConstants[name]; !ok {
-		return nil
-	}
-	value, ok := valueNode.(*ast.BasicLit)
-
-	if !ok {
-		return nil
-	}
-	return &value.Value
+// extractor collects the translation keys of the walked source files.
+type extractor struct {
+	registry *funcRegistry
+	consts   *constResolver
+	result   *extractResult
+	// files are the walked source files, scanned once the walk is done.
+	files []string
+	// scanned are resolved once every file is scanned, their keys may
+	// reference constants of any other file.
+	scanned []scannedPackageFile
+	errors  extractErrors
+}
 
+type scannedPackageFile struct {
+	scannedFile
+	PkgPath string
 }
 
-func extractFromPath(path string, info os.FileInfo, err error, i18nStrings map[string]bool) error {
-	if strings.HasSuffix(path, "model/client4.go") {
-		return nil
-	}
-	if strings.HasSuffix(path, "_test.go") {
-		return nil
-	}
-	if !strings.HasSuffix(path, ".go") {
-		return nil
-	}
-	if strings.Contains(path, ".git/") || strings.HasPrefix(path, ".git/") {
-		return nil
+func newExtractor(config *i18nConfig) *extractor {
+	return &extractor{
+		registry: newFuncRegistry(config.Functions),
+		consts:   newConstResolver(),
+		result:   &extractResult{Usages: i18nUsages{}},
 	}
+}
 
-	src, err := ioutil.ReadFile(path)
+// walkDir collects the Go files of dir not skipped by its ignore rules.
+func (e *extractor) walkDir(dir string) error {
+	rules, err := loadIgnoreRules(dir)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// A missing folder, like the enterprise one, is not a failure.
+			if !os.IsNotExist(err) {
+				e.errors = append(e.errors, newFileError(path, err))
+			}
+			return nil
+		}
+		if rules.ignored(path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".go") {
+			e.files = append(e.files, path)
+		}
+		return nil
+	})
+}
 
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, "", src, 0)
-	if err != nil {
-		panic(err)
+// scan summarizes the walked files and records their constants, skipping
+// those excluded by their build constraints. The calls of generated files are
+// not recorded, only their constants. Files are
+// processed in walk order, so the outcome does not depend on jobs.
+func (e *extractor) scan(jobs int, tags buildTags, cache *scanCache) {
+	for _, file := range scanFiles(e.files, jobs, tags, e.registry, cache) {
+		if file.Err != nil {
+			e.errors = append(e.errors, newFileError(file.Path, file.Err))
+			continue
+		}
+		if file.Summary == nil {
+			continue
+		}
+		pkgPath := e.consts.filePackagePath(file.Path)
+		e.consts.addConsts(file.Summary.Consts, pkgPath)
+		if file.Summary.Generated {
+			continue
+		}
+		e.scanned = append(e.scanned, scannedPackageFile{scannedFile: file, PkgPath: pkgPath})
 	}
+	e.files = nil
+}
 
-	ast.Inspect(f, func(n ast.Node) bool {
-		var id *string = nil
-
-		switch expr := n.(type) {
-		case *ast.CallExpr:
-			switch fun := expr.Fun.(type) {
-			case *ast.SelectorExpr:
-				id = extractByFuncName(fun.Sel.Name, expr.Args)
-				if id == nil {
-					return true
+// resolveCalls records the calls of the scanned files now that every
+// constant of the scanned code is known.
+func (e *extractor) resolveCalls() {
+	for _, file := range e.scanned {
+		for _, c := range file.Summary.Calls {
+			id, ok := e.consts.resolve(c.Key, file.PkgPath)
+			if !ok {
+				if !c.Annotated {
+					e.result.DynamicCalls = append(e.result.DynamicCalls, dynamicCall{
+						keyLocation: keyLocation{File: file.Path, Line: c.Line},
+						Func:        c.Func,
+						Key:         c.KeyExpr,
+					})
 				}
-				break
-			case *ast.Ident:
-				id = extractByFuncName(fun.Name, expr.Args)
-				break
-			default:
-				return true
+				continue
 			}
-			break
-		case *ast.GenDecl:
-			if expr.Tok == token.CONST {
-				for _, spec := range expr.Specs {
-					valueSpec, ok := spec.(*ast.ValueSpec)
+			var params map[string]bool
+			knownParams := c.KnownParams
+			if knownParams {
+				params = map[string]bool{}
+				for _, expr := range c.Params {
+					name, ok := e.consts.resolve(expr, file.PkgPath)
 					if !ok {
-						continue
-					}
-					if len(valueSpec.Names) == 0 {
-						continue
+						params, knownParams = nil, false
+						break
 					}
-					if len(valueSpec.Values) == 0 {
-						continue
-					}
-					id = extractForConstants(valueSpec.Names[0].Name, valueSpec.Values[0])
-					if id == nil {
-						continue
-					}
-					i18nStrings[strings.Trim(*id, "\"")] = true
+					params[name] = true
 				}
 			}
-			return true
-		default:
-			return true
-		}
-
-		if id != nil {
-			i18nStrings[strings.Trim(*id, "\"")] = true
+			e.result.addKey(translationCall{
+				keyLocation: keyLocation{File: file.Path, Line: c.Line},
+				ID:          id,
+				Func:        c.Func,
+				Package:     file.Summary.Package,
+				Params:      params,
+				KnownParams: knownParams,
+			})
 		}
-
-		return true
-	})
-	return nil
+	}
+	e.scanned = nil
 }
 
 func checkEmptySrcCmdF(command *cobra.Command, args []string) error {
-	enterpriseDir, err := command.Flags().GetString("enterprise-dir")
-	if err != nil {
-		return errors.New("invalid enterprise-dir parameter")
-	}
-	mattermostDir, err := command.Flags().GetString("mattermost-dir")
+	r, err := newReporter(command)
 	if err != nil {
-		return errors.New("invalid mattermost-dir parameter")
-	}
-	portalDir, err := command.Flags().GetString("portal-dir")
-	if err != nil {
-		return errors.New("invalid portal-dir parameter")
-	}
-	translationDir := path.Join(mattermostDir, "i18n")
-	if portalDir != "" {
-		if enterpriseDir != "" || mattermostDir != "" {
-			return errors.New("please specify EITHER portal-dir or enterprise-dir/mattermost-dir")
-		}
-		translationDir = portalDir
+		return err
 	}
-	srcJSON, err := ioutil.ReadFile(path.Join(translationDir, "en.json"))
+	translationDir, err := getLocaleDir(command)
 	if err != nil {
 		return err
 	}
-	var items []Item
-	if err = json.Unmarshal(srcJSON, &items); err != nil {
+	filename := path.Join(translationDir, baseLocaleFile)
+	items, err := loadItems(filename)
+	if err != nil {
 		return err
 	}
-	err = countEmptyItems(items)
-	if err != nil {
+	emptyErr := countEmptyItems(items, r, filename)
+	if err = r.flush(); err != nil {
 		return err
 	}
-	return nil
+	return emptyErr
 }
 
-func countEmptyItems(items []Item) error {
+func countEmptyItems(items []Item, r *reporter, filename string) error {
 	hasError := false
 	for _, t := range items {
 		str := string(t.Translation)
@@ -584,7 +705,18 @@ func countEmptyItems(items []Item) error {
 			return fmt.Errorf("error unquoting translation for %s, %v", t.ID, err)
 		}
 		if strings.TrimSpace(unquoted) == "" {
-			log.Printf("Empty translation for %s. Please fix it.\n", t.ID)
+			if r.isText() {
+				log.Printf("Empty translation for %s. Please fix it.\n", t.ID)
+			} else {
+				r.report("", finding{
+					RuleID:   "empty-source",
+					Severity: severityError,
+					Key:      t.ID,
+					File:     filename,
+					Line:     r.keyLine(filename, t.ID),
+					Message:  "empty translation source string",
+				})
+			}
 			hasError = true
 		}
 	}
@@ -595,6 +727,10 @@ func countEmptyItems(items []Item) error {
 }
 
 func cleanEmptyCmdF(command *cobra.Command, args []string) error {
+	r, err := newReporter(command)
+	if err != nil {
+		return err
+	}
 	dryRun, err := command.Flags().GetBool("dry-run")
 	if err != nil {
 		return errors.New("invalid dry-run parameter")
@@ -603,109 +739,96 @@ func cleanEmptyCmdF(command *cobra.Command, args []string) error {
 	if err != nil {
 		return errors.New("invalid check parameter")
 	}
-	enterpriseDir, err := command.Flags().GetString("enterprise-dir")
-	if err != nil {
-		return errors.New("invalid enterprise-dir parameter")
-	}
-	mattermostDir, err := command.Flags().GetString("mattermost-dir")
+	translationDir, err := getLocaleDir(command)
 	if err != nil {
-		return errors.New("invalid mattermost-dir parameter")
-	}
-	portalDir, err := command.Flags().GetString("portal-dir")
-	if err != nil {
-		return errors.New("invalid portal-dir parameter")
-	}
-	translationDir := path.Join(mattermostDir, "i18n")
-	if portalDir != "" {
-		if enterpriseDir != "" || mattermostDir != "" {
-			return errors.New("please specify EITHER portal-dir or enterprise-dir/mattermost-dir")
-		}
-		translationDir = portalDir
+		return err
 	}
-
-	var shippedFiles []string
-	files, err := ioutil.ReadDir(translationDir)
+	shippedFiles, err := getLocaleFiles(translationDir)
 	if err != nil {
 		return err
 	}
-	for _, file := range files {
-		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" && file.Name() != "en.json" {
-			shippedFiles = append(shippedFiles, file.Name())
-		}
-	}
 
 	results := ""
 	for _, file := range shippedFiles {
-		result, err2 := clean(translationDir, file, dryRun, check)
+		result, err2 := clean(translationDir, file, dryRun, check, r)
 		if err2 != nil {
 			return err2
 		}
 		results += *result
 	}
+	if err = r.flush(); err != nil {
+		return err
+	}
 	if results == "" {
 		return nil
 	}
-	fmt.Print("\n" + results)
+	if r.isText() {
+		fmt.Print("\n" + results)
+	}
 	if check {
 		os.Exit(1)
 	}
 	return nil
 }
 
-func clean(translationDir string, file string, dryRun bool, check bool) (*string, error) {
-	oldJSON, err := ioutil.ReadFile(path.Join(translationDir, file))
+func clean(translationDir string, file string, dryRun bool, check bool, r *reporter) (*string, error) {
+	filename := path.Join(translationDir, file)
+	oldList, err := loadItems(filename)
 	if err != nil {
 		return nil, err
 	}
-
-	var oldList []Item
-	if err = json.Unmarshal(oldJSON, &oldList); err != nil {
-		return nil, err
-	}
-	newList, count := removeEmptyTranslations(oldList)
+	newList, removed := removeEmptyTranslations(oldList)
 	result := ""
-	if count == 0 {
+	if len(removed) == 0 {
 		return &result, nil
 	}
-	result = fmt.Sprintf("%v has %v empty translations\n", file, count)
+	result = fmt.Sprintf("%v has %v empty translations\n", file, len(removed))
+	severity, message := severityWarning, "empty translation removed"
 	if dryRun || check {
-		return &result, nil
+		message = "empty translation"
 	}
-
-	newJSON, err := JSONMarshal(newList)
-	if err != nil {
-		return nil, err
+	if check {
+		severity = severityError
+	}
+	for _, id := range removed {
+		r.report("", finding{
+			RuleID:   "empty-translation",
+			Severity: severity,
+			Key:      id,
+			File:     filename,
+			Line:     r.keyLine(filename, id),
+			Message:  message,
+		})
 	}
-	filename := path.Join(translationDir, file)
-	fileInfo, err := os.Lstat(filename)
-	if err != nil {
-		return nil, err
+	if dryRun || check {
+		return &result, nil
 	}
-	if err = ioutil.WriteFile(filename, newJSON, fileInfo.Mode().Perm()); err != nil {
+
+	if err = writeLocaleFile(filename, newList); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
-func removeEmptyTranslations(oldList []Item) ([]Item, int) {
-	var count int
+func removeEmptyTranslations(oldList []Item) ([]Item, []string) {
+	var removed []string
 	var newList []Item
 	for i, t := range oldList {
 		if string(t.Translation) != "\"\"" {
 			newList = append(newList, oldList[i])
 		} else {
-			count++
+			removed = append(removed, t.ID)
 		}
 
 	}
-	return newList, count
+	return newList, removed
 }
 
 func JSONMarshal(t interface{}) ([]byte, error) {
 	buffer := &bytes.Buffer{}
 	encoder := json.NewEncoder(buffer)
 	encoder.SetEscapeHTML(false)
-	encoder.SetIndent("", "    ")
+	encoder.SetIndent("", "  ")
 	err := encoder.Encode(t)
 	return buffer.Bytes(), err
 }
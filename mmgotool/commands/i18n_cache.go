@@ -0,0 +1,120 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const scanCacheFileName = "i18n-extract.json"
+
+// scanCacheVersion is bumped whenever fileSummary changes, discarding the
+// caches written by previous versions.
+const scanCacheVersion = 5
+
+// scanCache holds the summaries of the scanned files by content hash. A nil
+// scanCache caches nothing.
+type scanCache struct {
+	filename    string
+	fingerprint string
+	mutex       sync.Mutex
+	loaded      map[string]*fileSummary
+	used        map[string]*fileSummary
+}
+
+type scanCacheFile struct {
+	Version int `json:"version"`
+	// Fingerprint identifies the translation functions the summaries were
+	// collected with.
+	Fingerprint string                  `json:"fingerprint"`
+	Files       map[string]*fileSummary `json:"files"`
+}
+
+// defaultScanCacheDir returns the folder of the scan cache in the user cache
+// folder, or an empty path disabling the cache when there is none.
+func defaultScanCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mmgotool")
+}
+
+// loadScanCache reads the cache stored in dir for the given translation
+// functions. A missing, unreadable or outdated cache is started afresh.
+func loadScanCache(dir string, functions []translationFunc) *scanCache {
+	data, _ := json.Marshal(functions)
+	sum := sha256.Sum256(data)
+	cache := &scanCache{
+		filename:    filepath.Join(dir, scanCacheFileName),
+		fingerprint: hex.EncodeToString(sum[:]),
+		loaded:      map[string]*fileSummary{},
+		used:        map[string]*fileSummary{},
+	}
+
+	data, err := ioutil.ReadFile(cache.filename)
+	if err != nil {
+		return cache
+	}
+	var stored scanCacheFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return cache
+	}
+	if stored.Version == scanCacheVersion && stored.Fingerprint == cache.fingerprint && stored.Files != nil {
+		cache.loaded = stored.Files
+	}
+	return cache
+}
+
+func (c *scanCache) get(hash string) (*fileSummary, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	summary, ok := c.loaded[hash]
+	if ok {
+		c.used[hash] = summary
+	}
+	return summary, ok
+}
+
+func (c *scanCache) put(hash string, summary *fileSummary) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.used[hash] = summary
+}
+
+// save writes the summaries of the files scanned in this run, dropping those
+// of files that no longer exist or changed. The file is replaced atomically
+// so concurrent runs never read a partial cache.
+func (c *scanCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data, err := json.Marshal(scanCacheFile{
+		Version:     scanCacheVersion,
+		Fingerprint: c.fingerprint,
+		Files:       c.used,
+	})
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(c.filename), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomically(c.filename, data, 0600)
+}
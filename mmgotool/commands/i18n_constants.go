@@ -0,0 +1,232 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxConstDepth bounds the number of constants followed while evaluating a
+// single expression, protecting against reference cycles.
+const maxConstDepth = 32
+
+// constExpr is a translation key expression reduced to what is needed to
+// evaluate it once every file is scanned: string literals, references to
+// package level constants and concatenations. Unlike the syntax tree it can be
+// stored in the scan cache. A nil constExpr is never known at compile time.
+type constExpr struct {
+	Value *string `json:"value,omitempty"`
+	// Pkg is the import path of the package declaring the constant Name,
+	// empty for the package of the file holding the expression.
+	Pkg    string       `json:"pkg,omitempty"`
+	Name   string       `json:"name,omitempty"`
+	Concat []*constExpr `json:"concat,omitempty"`
+}
+
+// newConstExpr reduces expr, found in a file importing imports. Constants
+// declared in the same file are inlined as the syntax tree links them to
+// their declaration.
+func newConstExpr(expr ast.Expr, imports map[string]string) *constExpr {
+	return reduceConstExpr(expr, imports, 0)
+}
+
+func reduceConstExpr(expr ast.Expr, imports map[string]string, depth int) *constExpr {
+	if depth > maxConstDepth {
+		return nil
+	}
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return nil
+		}
+		value := constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		if value.Kind() != constant.String {
+			return nil
+		}
+		s := constant.StringVal(value)
+		return &constExpr{Value: &s}
+	case *ast.ParenExpr:
+		return reduceConstExpr(e.X, imports, depth)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return nil
+		}
+		x := reduceConstExpr(e.X, imports, depth)
+		y := reduceConstExpr(e.Y, imports, depth)
+		if x == nil || y == nil {
+			return nil
+		}
+		return &constExpr{Concat: []*constExpr{x, y}}
+	case *ast.Ident:
+		if e.Obj != nil {
+			// Declared in the same file, either locally or at package level.
+			if e.Obj.Kind != ast.Con {
+				return nil
+			}
+			valueSpec, ok := e.Obj.Decl.(*ast.ValueSpec)
+			if !ok {
+				return nil
+			}
+			for i, name := range valueSpec.Names {
+				if name.Name == e.Name && i < len(valueSpec.Values) {
+					return reduceConstExpr(valueSpec.Values[i], imports, depth+1)
+				}
+			}
+			return nil
+		}
+		return &constExpr{Name: e.Name}
+	case *ast.SelectorExpr:
+		pkg, ok := e.X.(*ast.Ident)
+		if !ok || pkg.Obj != nil {
+			return nil
+		}
+		pkgPath, ok := imports[pkg.Name]
+		if !ok {
+			return nil
+		}
+		return &constExpr{Pkg: pkgPath, Name: e.Sel.Name}
+	}
+	return nil
+}
+
+// fileConsts returns the package level constants declared in f.
+func fileConsts(f *ast.File, imports map[string]string) map[string]*constExpr {
+	consts := map[string]*constExpr{}
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for i, name := range valueSpec.Names {
+				if i >= len(valueSpec.Values) || name.Name == "_" {
+					continue
+				}
+				consts[name.Name] = newConstExpr(valueSpec.Values[i], imports)
+			}
+		}
+	}
+	return consts
+}
+
+type constDecl struct {
+	Expr    *constExpr
+	PkgPath string
+}
+
+// constResolver evaluates translation key expressions. Package level
+// constants are collected from every scanned file and looked up by package
+// import path, so references like model.SomeErrorID are resolved across
+// packages.
+type constResolver struct {
+	consts   map[string]map[string]*constDecl
+	pkgPaths map[string]string
+}
+
+func newConstResolver() *constResolver {
+	return &constResolver{
+		consts:   map[string]map[string]*constDecl{},
+		pkgPaths: map[string]string{},
+	}
+}
+
+// filePackagePath returns the import path of the package of the file found
+// at filename.
+func (r *constResolver) filePackagePath(filename string) string {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		dir = filepath.Dir(filename)
+	}
+	return r.packagePath(dir)
+}
+
+// packagePath returns the import path of the package in dir, derived from the
+// module path of the closest go.mod file. Without a go.mod file the directory
+// itself identifies the package.
+func (r *constResolver) packagePath(dir string) string {
+	if pkgPath, ok := r.pkgPaths[dir]; ok {
+		return pkgPath
+	}
+	pkgPath := dir
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "go.mod")); err == nil && modulePath(data) != "" {
+		pkgPath = modulePath(data)
+	} else if parent := filepath.Dir(dir); parent != dir {
+		pkgPath = path.Join(r.packagePath(parent), filepath.Base(dir))
+	}
+	r.pkgPaths[dir] = pkgPath
+	return pkgPath
+}
+
+func modulePath(goMod []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(goMod))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "module" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+			return unquoted
+		}
+		return fields[1]
+	}
+	return ""
+}
+
+// addConsts records the package level constants of a file of the package
+// pkgPath.
+func (r *constResolver) addConsts(consts map[string]*constExpr, pkgPath string) {
+	for name, expr := range consts {
+		if r.consts[pkgPath] == nil {
+			r.consts[pkgPath] = map[string]*constDecl{}
+		}
+		r.consts[pkgPath][name] = &constDecl{Expr: expr, PkgPath: pkgPath}
+	}
+}
+
+// resolve returns the string value of expr, found in the package pkgPath,
+// when it is known at compile time.
+func (r *constResolver) resolve(expr *constExpr, pkgPath string) (string, bool) {
+	return r.eval(expr, pkgPath, 0)
+}
+
+func (r *constResolver) eval(expr *constExpr, pkgPath string, depth int) (string, bool) {
+	if expr == nil || depth > maxConstDepth {
+		return "", false
+	}
+	switch {
+	case expr.Value != nil:
+		return *expr.Value, true
+	case expr.Concat != nil:
+		var value strings.Builder
+		for _, part := range expr.Concat {
+			s, ok := r.eval(part, pkgPath, depth)
+			if !ok {
+				return "", false
+			}
+			value.WriteString(s)
+		}
+		return value.String(), true
+	}
+	if expr.Pkg != "" {
+		pkgPath = expr.Pkg
+	}
+	decl, ok := r.consts[pkgPath][expr.Name]
+	if !ok {
+		return "", false
+	}
+	return r.eval(decl.Expr, decl.PkgPath, depth+1)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractResolvesConstants(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"go.mod": "module github.com/mattermost/mattermost-server/v6\n\ngo 1.16\n",
+		"app/a.go": `package app
+
+import (
+	m "github.com/mattermost/mattermost-server/v6/model"
+)
+
+const prefix = "app.channel."
+
+func f(dynamic string) {
+	const local = "app.local"
+	c.T(local)
+	c.T(prefix + "create" + ".app_error")
+	c.T(samePackage)
+	m.NewAppError("f", m.MissingChannelError, nil, "", 0)
+	m.NewAppError("f", m.Nested, nil, "", 0)
+	c.T(prefix + dynamic)
+	c.T(m.Cycle)
+}
+`,
+		"app/b.go": `package app
+
+const samePackage = prefix + "same_package"
+`,
+		"model/errors.go": `package model
+
+const (
+	MissingChannelError = "model.channel.missing.app_error"
+	Nested              = "model." + (base + ".nested")
+	Cycle               = Other
+	Other               = Cycle
+)
+
+const base = "base"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	opts := &extractOptions{MattermostDir: dir, TranslationDir: dir, SkipDynamic: true, Config: defaultI18nConfig()}
+	result, err := extractSrcStrings(opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"app.channel.create.app_error",
+		"app.channel.same_package",
+		"app.local",
+		"model.base.nested",
+		"model.channel.missing.app_error",
+	}, result.Usages.keys())
+
+	var dynamic []string
+	for _, call := range result.DynamicCalls {
+		dynamic = append(dynamic, call.Key)
+	}
+	assert.Equal(t, []string{"prefix + dynamic", "m.Cycle"}, dynamic)
+}
+
+func TestModulePath(t *testing.T) {
+	assert.Equal(t, "github.com/mattermost/mattermost-server/v6", modulePath([]byte("// comment\nmodule github.com/mattermost/mattermost-server/v6\n")))
+	assert.Equal(t, "example.com/quoted", modulePath([]byte(`module "example.com/quoted"`)))
+	assert.Equal(t, "", modulePath([]byte("go 1.16\n")))
+}
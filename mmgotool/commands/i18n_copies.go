@@ -0,0 +1,213 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+var CheckCopiesCmd = &cobra.Command{
+	Use:     "check-copies",
+	Short:   "Check for translations copied from English",
+	Long:    "Check the locale files for translations identical to their i18n/en.json source string, other than those allowed in the english_copies section of " + i18nConfigFileName + ". The locales matching its ignored_locales patterns, by default the English variants en-*, are left unchecked.",
+	Example: "  i18n check-copies --strip",
+	RunE:    checkCopiesCmdF,
+}
+
+func init() {
+	CheckCopiesCmd.Flags().Bool("strip", false, "Remove the translations copied from English from the locale files instead of failing")
+	CheckCopiesCmd.Flags().Bool("dry-run", false, "Run without applying changes")
+	CheckCopiesCmd.Flags().String("config", "", "Path to the i18n configuration file (defaults to "+i18nConfigFileName+" in the source code folder)")
+	addLocaleDirFlags(CheckCopiesCmd)
+	addFormatFlag(CheckCopiesCmd)
+
+	I18nCmd.AddCommand(CheckCopiesCmd)
+}
+
+// copyChecker finds the translations identical to their English source
+// string.
+type copyChecker struct {
+	source         map[string]interface{}
+	allowedKeys    map[string]bool
+	allowedStrings map[string]bool
+	ignoredLocales []string
+}
+
+func newCopyChecker(source []Translation, config englishCopiesConfig) *copyChecker {
+	c := &copyChecker{
+		source:         make(map[string]interface{}, len(source)),
+		allowedKeys:    map[string]bool{},
+		allowedStrings: map[string]bool{},
+		ignoredLocales: config.IgnoredLocales,
+	}
+	for _, t := range source {
+		c.source[t.Id] = t.Translation
+	}
+	for _, key := range config.AllowedKeys {
+		c.allowedKeys[key] = true
+	}
+	for _, text := range config.AllowedStrings {
+		c.allowedStrings[text] = true
+	}
+	return c
+}
+
+// ignoresLocale tells whether the translations of locale may all be copies,
+// like those of the English variants.
+func (c *copyChecker) ignoresLocale(locale string) bool {
+	for _, pattern := range c.ignoredLocales {
+		if matched, _ := path.Match(pattern, locale); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isCopy tells whether the translation of id is a copy of its source string.
+// Allowed keys and strings are not, nor are empty translations and strings
+// without words to translate, like "{{.Count}}".
+func (c *copyChecker) isCopy(id string, translation interface{}) bool {
+	src, ok := c.source[id]
+	if !ok || c.allowedKeys[id] || isEmptyTranslation(translation) {
+		return false
+	}
+	forms, srcForms := translationForms(translation), translationForms(src)
+	if len(forms) != len(srcForms) {
+		return false
+	}
+	translatable := false
+	for form, text := range forms {
+		if srcText, ok := srcForms[form]; !ok || srcText != text {
+			return false
+		}
+		if !c.allowedStrings[text] && hasWords(text) {
+			translatable = true
+		}
+	}
+	return translatable
+}
+
+// removeCopies returns the items of a locale file without the translations
+// copied from English, along with the keys of the removed ones.
+func (c *copyChecker) removeCopies(items []Item) ([]Item, []string, error) {
+	var kept []Item
+	var copied []string
+	for _, item := range items {
+		var translation interface{}
+		if err := json.Unmarshal(item.Translation, &translation); err != nil {
+			return nil, nil, fmt.Errorf("invalid translation of %s: %v", item.ID, err)
+		}
+		if c.isCopy(item.ID, translation) {
+			copied = append(copied, item.ID)
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept, copied, nil
+}
+
+// hasWords tells whether text holds letters outside of its template actions.
+func hasWords(text string) bool {
+	for _, r := range templateActionRegexp.ReplaceAllString(text, "") {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkCopiesCmdF(command *cobra.Command, args []string) error {
+	r, err := newReporter(command)
+	if err != nil {
+		return err
+	}
+	strip, err := command.Flags().GetBool("strip")
+	if err != nil {
+		return errors.New("invalid strip parameter")
+	}
+	dryRun, err := command.Flags().GetBool("dry-run")
+	if err != nil {
+		return errors.New("invalid dry-run parameter")
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	config, err := getLocaleConfig(command)
+	if err != nil {
+		return err
+	}
+	source, err := loadTranslations(path.Join(translationDir, baseLocaleFile))
+	if err != nil {
+		return err
+	}
+	files, err := getLocaleFiles(translationDir)
+	if err != nil {
+		return err
+	}
+
+	checker := newCopyChecker(source, config.EnglishCopies)
+	severity, message := severityError, "translation copied from English"
+	if strip {
+		severity = severityWarning
+		if !dryRun {
+			message = "translation copied from English removed"
+		}
+	}
+	var results []string
+	for _, file := range files {
+		if checker.ignoresLocale(strings.TrimSuffix(file, ".json")) {
+			continue
+		}
+		filename := path.Join(translationDir, file)
+		items, err2 := loadItems(filename)
+		if err2 != nil {
+			return err2
+		}
+		kept, copied, err2 := checker.removeCopies(items)
+		if err2 != nil {
+			return fmt.Errorf("%s: %v", filename, err2)
+		}
+		if len(copied) == 0 {
+			continue
+		}
+		results = append(results, fmt.Sprintf("%v has %v translations copied from English\n", file, len(copied)))
+		for _, id := range copied {
+			r.report(fmt.Sprintf("%s: %s", filename, id), finding{
+				RuleID:   "english-copy",
+				Severity: severity,
+				Key:      id,
+				File:     filename,
+				Line:     r.keyLine(filename, id),
+				Message:  message,
+			})
+		}
+		if strip && !dryRun {
+			if err2 = writeLocaleFile(filename, kept); err2 != nil {
+				return err2
+			}
+		}
+	}
+	if err = r.flush(); err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	if r.isText() {
+		fmt.Print("\n" + strings.Join(results, ""))
+	}
+	if !strip {
+		command.SilenceUsage = true
+		return errors.New("translations copied from English found")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyChecker(t *testing.T) {
+	var source []Translation
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"id": "app.copied", "translation": "Save changes"},
+		{"id": "app.translated", "translation": "Save changes"},
+		{"id": "app.product", "translation": "Mattermost"},
+		{"id": "app.allowed", "translation": "Status"},
+		{"id": "app.count", "translation": "{{.Count}} / {{.Total}}"},
+		{"id": "app.empty", "translation": ""},
+		{"id": "app.plural", "translation": {"one": "{{.Count}} file", "other": "{{.Count}} files"}},
+		{"id": "app.plural_forms", "translation": {"one": "{{.Count}} file", "other": "{{.Count}} files"}}
+	]`), &source))
+	checker := newCopyChecker(source, englishCopiesConfig{AllowedKeys: []string{"app.allowed"}, AllowedStrings: []string{"Mattermost"}})
+
+	var items []Item
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"id": "app.copied", "translation": "Save changes"},
+		{"id": "app.translated", "translation": "Enregistrer"},
+		{"id": "app.product", "translation": "Mattermost"},
+		{"id": "app.allowed", "translation": "Status"},
+		{"id": "app.count", "translation": "{{.Count}} / {{.Total}}"},
+		{"id": "app.empty", "translation": ""},
+		{"id": "app.plural", "translation": {"other": "{{.Count}} files", "one": "{{.Count}} file"}},
+		{"id": "app.plural_forms", "translation": {"one": "{{.Count}} file", "few": "{{.Count}} files", "other": "{{.Count}} files"}},
+		{"id": "app.orphaned", "translation": "Orphaned"}
+	]`), &items))
+	kept, copied, err := checker.removeCopies(items)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.copied", "app.plural"}, copied)
+	var ids []string
+	for _, item := range kept {
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []string{"app.translated", "app.product", "app.allowed", "app.count", "app.empty", "app.plural_forms", "app.orphaned"}, ids)
+}
+
+func TestCopyCheckerIgnoresLocale(t *testing.T) {
+	checker := newCopyChecker(nil, defaultI18nConfig().EnglishCopies)
+	assert.True(t, checker.ignoresLocale("en-AU"))
+	assert.False(t, checker.ignoresLocale("fr"))
+
+	checker = newCopyChecker(nil, englishCopiesConfig{IgnoredLocales: []string{"pt-*", "zh-TW"}})
+	assert.True(t, checker.ignoresLocale("pt-BR"))
+	assert.True(t, checker.ignoresLocale("zh-TW"))
+	assert.False(t, checker.ignoresLocale("en-AU"))
+}
+
+func TestHasWords(t *testing.T) {
+	assert.True(t, hasWords("Save"))
+	assert.True(t, hasWords("{{.Count}} éléments"))
+	assert.False(t, hasWords("{{.Count}} / {{.Total}}"))
+	assert.False(t, hasWords("100%"))
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const defaultDynamicKeysFile = "i18n/dynamic_keys.txt"
+
+const dynamicKeyRegexpPrefix = "re:"
+
+// dynamicKey is an entry of the dynamic keys file. It is either a literal
+// translation key, a glob pattern like "model.user.is_valid.pwd_*" or a
+// regular expression prefixed with "re:".
+type dynamicKey struct {
+	Entry  string
+	Source string
+	Line   int
+	re     *regexp.Regexp
+	glob   bool
+}
+
+func (k *dynamicKey) isLiteral() bool {
+	return k.re == nil && !k.glob
+}
+
+func (k *dynamicKey) match(id string) bool {
+	if k.re != nil {
+		return k.re.MatchString(id)
+	}
+	if k.glob {
+		matched, _ := path.Match(k.Entry, id)
+		return matched
+	}
+	return k.Entry == id
+}
+
+func parseDynamicKey(entry, source string, line int) (*dynamicKey, error) {
+	key := &dynamicKey{Entry: entry, Source: source, Line: line}
+	if strings.HasPrefix(entry, dynamicKeyRegexpPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(entry, dynamicKeyRegexpPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid regular expression: %v", source, line, err)
+		}
+		key.re = re
+		return key, nil
+	}
+	if strings.ContainsAny(entry, "*?[") {
+		if _, err := path.Match(entry, ""); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern: %v", source, line, err)
+		}
+		key.glob = true
+	}
+	return key, nil
+}
+
+// loadDynamicKeys reads the dynamic keys file configured for rootDir. Empty
+// lines and lines starting with "#" are ignored. A missing configured file is
+// an error, while a repository without the default file has no dynamic keys,
+// which is logged as every dynamically built key would then be removed.
+func loadDynamicKeys(rootDir string, config *i18nConfig) ([]*dynamicKey, error) {
+	filename := config.DynamicKeysFile
+	configured := filename != ""
+	if !configured {
+		filename = defaultDynamicKeysFile
+	}
+	if !path.IsAbs(filename) {
+		filename = path.Join(rootDir, filename)
+	}
+
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		if configured {
+			return nil, fmt.Errorf("dynamic keys file %s not found", filename)
+		}
+		log.Printf("Warning: %s not found, no key is considered built at runtime\n", filename)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var keys []*dynamicKey
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		key, err := parseDynamicKey(entry, filename, line)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// addDynamicallyGeneratedStrings marks the literal dynamic keys and every key
+// of the base file matching a dynamic pattern as used by the dynamic keys
+// file entry.
+func addDynamicallyGeneratedStrings(i18nStrings i18nUsages, dynamicKeys []*dynamicKey, baseKeys []string) {
+	for _, key := range dynamicKeys {
+		pos := token.Position{Filename: key.Source, Line: key.Line}
+		if key.isLiteral() {
+			i18nStrings.add(key.Entry, pos)
+			continue
+		}
+		for _, id := range baseKeys {
+			if key.match(id) {
+				i18nStrings.add(id, pos)
+			}
+		}
+	}
+}
+
+// unmatchedDynamicKeys returns the dynamic keys entries that do not match any
+// key of the base file.
+func unmatchedDynamicKeys(dynamicKeys []*dynamicKey, baseKeys []string) []*dynamicKey {
+	var unmatched []*dynamicKey
+	for _, key := range dynamicKeys {
+		found := false
+		for _, id := range baseKeys {
+			if key.match(id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, key)
+		}
+	}
+	return unmatched
+}
+
+// dynamicCallAnnotation acknowledges an intentional translation call whose key
+// is built at runtime when found in a comment on the line of the call or in a
+// comment alone on the line above it.
+const dynamicCallAnnotation = "i18n:dynamic"
+
+// dynamicCall is a call to a translation function whose key argument is not a
+// string literal.
+type dynamicCall struct {
+	keyLocation
+	Func string `json:"func"`
+	Key  string `json:"key"`
+}
+
+func newDynamicCall(pos token.Position, call *ast.CallExpr, arg ast.Expr) dynamicCall {
+	return dynamicCall{
+		keyLocation: keyLocation{File: pos.Filename, Line: pos.Line},
+		Func:        types.ExprString(call.Fun),
+		Key:         types.ExprString(arg),
+	}
+}
+
+func (c dynamicCall) String() string {
+	return fmt.Sprintf("%s: %s(%s)", c.keyLocation, c.Func, c.Key)
+}
+
+// dynamicAnnotations are the lines of a file holding a comment with the
+// dynamic call annotation.
+type dynamicAnnotations struct {
+	lines map[int]bool
+	// standalone are the annotated lines holding nothing but comments.
+	standalone map[int]bool
+}
+
+// covers tells whether a call on line is annotated, by a comment on the same
+// line or alone on the line above. A comment trailing the code of the line
+// above only annotates that code.
+func (a dynamicAnnotations) covers(line int) bool {
+	return a.lines[line] || a.standalone[line-1]
+}
+
+// annotatedLines returns the lines of the file holding a comment with the
+// dynamic call annotation.
+func annotatedLines(fset *token.FileSet, f *ast.File) dynamicAnnotations {
+	a := dynamicAnnotations{lines: map[int]bool{}, standalone: map[int]bool{}}
+	code := map[int]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+		code[fset.Position(n.Pos()).Line] = true
+		code[fset.Position(n.End()).Line] = true
+		return true
+	})
+	for _, group := range f.Comments {
+		for _, comment := range group.List {
+			if strings.Contains(comment.Text, dynamicCallAnnotation) {
+				line := fset.Position(comment.Pos()).Line
+				a.lines[line] = true
+				a.standalone[line] = !code[line]
+			}
+		}
+	}
+	return a
+}
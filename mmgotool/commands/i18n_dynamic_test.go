@@ -0,0 +1,126 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamicKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmgotool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := defaultI18nConfig()
+
+	t.Run("Missing default file means no dynamic keys", func(t *testing.T) {
+		keys, err := loadDynamicKeys(dir, config)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("Missing configured file is an error", func(t *testing.T) {
+		config := defaultI18nConfig()
+		config.DynamicKeysFile = "i18n/dynamic.txt"
+		_, err := loadDynamicKeys(dir, config)
+		assert.EqualError(t, err, "dynamic keys file "+filepath.Join(dir, "i18n/dynamic.txt")+" not found")
+	})
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "i18n"), 0700))
+	data := `# Password rules
+model.user.is_valid.pwd_*.app_error
+re:^month\.(jan|feb)$
+
+January
+model.unused_*
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, defaultDynamicKeysFile), []byte(data), 0600))
+
+	keys, err := loadDynamicKeys(dir, config)
+	require.NoError(t, err)
+	require.Len(t, keys, 4)
+	assert.Equal(t, 2, keys[0].Line)
+	assert.Equal(t, 5, keys[2].Line)
+
+	baseKeys := []string{
+		"January",
+		"model.user.is_valid.pwd_number.app_error",
+		"model.user.is_valid.pwd_symbol.app_error",
+		"model.user.is_valid.email.app_error",
+		"month.jan",
+		"month.mar",
+	}
+
+	t.Run("Literals and matching base keys are added", func(t *testing.T) {
+		i18nStrings := i18nUsages{}
+		addDynamicallyGeneratedStrings(i18nStrings, keys, baseKeys)
+		assert.Equal(t, []string{
+			"January",
+			"model.user.is_valid.pwd_number.app_error",
+			"model.user.is_valid.pwd_symbol.app_error",
+			"month.jan",
+		}, i18nStrings.keys())
+		assert.Equal(t, 5, i18nStrings["January"][0].Line)
+	})
+
+	t.Run("Entries matching no key are reported", func(t *testing.T) {
+		unmatched := unmatchedDynamicKeys(keys, baseKeys)
+		require.Len(t, unmatched, 1)
+		assert.Equal(t, "model.unused_*", unmatched[0].Entry)
+	})
+
+	t.Run("Invalid regular expressions are rejected", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, defaultDynamicKeysFile), []byte("re:(\n"), 0600))
+		_, err := loadDynamicKeys(dir, config)
+		assert.Error(t, err)
+	})
+}
+
+func TestServerDynamicKeys(t *testing.T) {
+	config := defaultI18nConfig()
+	config.DynamicKeysFile = "dynamic_keys.txt"
+	keys, err := loadDynamicKeys("..", config)
+	require.NoError(t, err)
+	assert.Len(t, keys, 42)
+	for _, key := range keys {
+		assert.True(t, key.isLiteral(), key.Entry)
+	}
+}
+
+func TestExtractDynamicCalls(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"app/a.go": `package app
+
+func f(key, prefix string) {
+	c.T("app.literal")
+	c.T(key)
+	model.NewAppError("f", prefix+".app_error", nil, "", 0)
+	c.T(key) // i18n:dynamic built from the rule name
+	// i18n:dynamic
+	c.T(key)
+	c.T(key) // i18n:dynamic
+	c.T(prefix)
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	opts := &extractOptions{MattermostDir: dir, TranslationDir: dir, SkipDynamic: true, Config: defaultI18nConfig()}
+	result, err := extractSrcStrings(opts)
+	require.NoError(t, err)
+
+	filename := filepath.Join(dir, "app/a.go")
+	assert.Equal(t, []dynamicCall{
+		{keyLocation: keyLocation{File: filename, Line: 5}, Func: "c.T", Key: "key"},
+		{keyLocation: keyLocation{File: filename, Line: 6}, Func: "model.NewAppError", Key: `prefix + ".app_error"`},
+		{keyLocation: keyLocation{File: filename, Line: 11}, Func: "c.T", Key: "prefix"},
+	}, result.DynamicCalls)
+	assert.Equal(t, filename+`:6: model.NewAppError(prefix + ".app_error")`, result.DynamicCalls[1].String())
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"fmt"
+	"go/scanner"
+	"os"
+	"strings"
+)
+
+// fileError is a file that could not be read or parsed while scanning the
+// source code.
+type fileError struct {
+	File    string `json:"file"`
+	Message string `json:"error"`
+}
+
+func newFileError(file string, err error) fileError {
+	if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+		message := fmt.Sprintf("line %d: %s", list[0].Pos.Line, list[0].Msg)
+		if len(list) > 1 {
+			message += fmt.Sprintf(" (and %d more errors)", len(list)-1)
+		}
+		return fileError{File: file, Message: message}
+	}
+	if pathErr, ok := err.(*os.PathError); ok {
+		return fileError{File: file, Message: pathErr.Op + ": " + pathErr.Err.Error()}
+	}
+	return fileError{File: file, Message: err.Error()}
+}
+
+// extractErrors reports every file that failed while scanning the source
+// code. Extraction goes on after a failure so all of them are listed at once.
+type extractErrors []fileError
+
+func (e extractErrors) Error() string {
+	header := fmt.Sprintf("failed to scan %d files:", len(e))
+	if len(e) == 1 {
+		header = "failed to scan 1 file:"
+	}
+	lines := []string{header}
+	for _, fe := range e {
+		lines = append(lines, fmt.Sprintf("  %s: %s", fe.File, fe.Message))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSrcStringsErrors(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"app/a.go": `package app
+
+func f() {
+	c.T("app.first")
+}
+`,
+		"app/broken.go": `package app
+
+func g() {
+	c.T("app.broken"
+}
+`,
+		"app/z.go": `package app
+
+func h() {
+	c.T("app.last")
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+	dangling := filepath.Join(dir, "app/dangling.go")
+	require.NoError(t, os.Symlink(filepath.Join(dir, "app/missing.go"), dangling))
+
+	opts := &extractOptions{MattermostDir: dir, TranslationDir: dir, SkipDynamic: true, Config: defaultI18nConfig()}
+	result, err := extractSrcStrings(opts)
+	require.Error(t, err)
+
+	errs, ok := err.(extractErrors)
+	require.True(t, ok)
+	require.Len(t, errs, 2)
+	assert.Equal(t, filepath.Join(dir, "app/broken.go"), errs[0].File)
+	assert.Contains(t, errs[0].Message, "line 4:")
+	assert.Equal(t, fileError{File: dangling, Message: "open: no such file or directory"}, errs[1])
+	assert.Contains(t, err.Error(), "failed to scan 2 files:")
+	assert.Equal(t, "failed to scan 1 file:\n  a.go: broken", extractErrors{{File: "a.go", Message: "broken"}}.Error())
+
+	assert.Equal(t, []string{"app.first", "app.last"}, result.Usages.keys())
+}
@@ -0,0 +1,419 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	exchangeFormatPO      = "po"
+	exchangeFormatXLIFF12 = "xliff"
+	exchangeFormatXLIFF20 = "xliff2"
+)
+
+var ExportCmd = &cobra.Command{
+	Use:     "export <locale>",
+	Short:   "Export translations to PO or XLIFF",
+	Long:    "Export the translations of a locale file paired with the i18n/en.json source strings as a Gettext PO, XLIFF 1.2 or XLIFF 2.0 file",
+	Example: "  i18n export fr --format xliff --output fr.xlf",
+	Args:    cobra.ExactArgs(1),
+	RunE:    exportCmdF,
+}
+
+var ImportCmd = &cobra.Command{
+	Use:     "import <locale> <file>",
+	Short:   "Import translations from PO or XLIFF",
+	Long:    "Merge the translations of a Gettext PO, XLIFF 1.2 or XLIFF 2.0 file into a locale file. Units without a target leave the locale file untouched, while empty targets and fuzzy PO entries remove the existing translation. Nothing is imported when a translation has plural forms that i18n check-plurals would report.",
+	Example: "  i18n import fr fr.po",
+	Args:    cobra.ExactArgs(2),
+	RunE:    importCmdF,
+}
+
+func init() {
+	addLocaleDirFlags(ExportCmd)
+	ExportCmd.Flags().String("format", exchangeFormatPO, "Output format, one of: po, xliff (XLIFF 1.2), xliff2 (XLIFF 2.0)")
+	ExportCmd.Flags().String("output", "", "Path to the exported file, standard output when empty")
+
+	addLocaleDirFlags(ImportCmd)
+	ImportCmd.Flags().String("format", "", "Input format, one of: po, xliff, xliff2 (guessed from the file when empty)")
+
+	I18nCmd.AddCommand(ExportCmd, ImportCmd)
+}
+
+// exchangeUnit is a translation paired with its en.json source string. Forms
+// are keyed by plural category, plain strings use the "" form.
+type exchangeUnit struct {
+	ID     string
+	Plural bool
+	Source map[string]string
+	// Target is empty when the translation is missing.
+	Target map[string]string
+	// Cleared is set when the file explicitly holds an empty or fuzzy
+	// target, the existing translation is then removed.
+	Cleared bool
+}
+
+// exchangeFile is the content of an exported file.
+type exchangeFile struct {
+	Locale string
+	// Categories are the plural categories of the locale, in CLDR order.
+	Categories []string
+	Units      []exchangeUnit
+}
+
+// canonicalPluralOrder is the CLDR order of the plural categories.
+var canonicalPluralOrder = []string{"zero", "one", "two", "few", "many", "other"}
+
+// newExchangeFile pairs the translations of locale with the source strings,
+// following the order of the source file. Keys absent from the source file
+// are not exported.
+func newExchangeFile(locale string, source []Translation, translations []Translation) *exchangeFile {
+	file := &exchangeFile{Locale: locale}
+	byID := translationsByID(translations)
+	categories := map[string]bool{}
+	for _, category := range pluralCategories(locale + ".json") {
+		categories[category] = true
+	}
+	for _, src := range source {
+		unit := exchangeUnit{ID: src.Id, Source: translationForms(src.Translation)}
+		_, unit.Plural = src.Translation.(map[string]interface{})
+		if t, ok := byID[src.Id]; ok && !isEmptyTranslation(t.Translation) {
+			unit.Target = translationForms(t.Translation)
+			// Translations whose shape differs from the source, reported by
+			// i18n check-plurals, follow the shape of the source.
+			_, isPlural := t.Translation.(map[string]interface{})
+			if unit.Plural && !isPlural {
+				unit.Target = map[string]string{pluralOther: unit.Target[""]}
+			} else if !unit.Plural && isPlural {
+				unit.Target = map[string]string{"": unit.Target[pluralOther]}
+			}
+		}
+		if unit.Plural {
+			categories[pluralOther] = true
+			for form := range unit.Target {
+				if form != "" {
+					categories[form] = true
+				}
+			}
+		}
+		file.Units = append(file.Units, unit)
+	}
+	file.Categories = sortPluralCategories(categories)
+	return file
+}
+
+func isPluralCategory(category string) bool {
+	for _, known := range canonicalPluralOrder {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// sortPluralCategories returns the categories in CLDR order, followed by
+// unknown ones in alphabetical order.
+func sortPluralCategories(categories map[string]bool) []string {
+	var sorted []string
+	known := map[string]bool{}
+	for _, category := range canonicalPluralOrder {
+		known[category] = true
+		if categories[category] {
+			sorted = append(sorted, category)
+		}
+	}
+	var unknown []string
+	for category := range categories {
+		if !known[category] {
+			unknown = append(unknown, category)
+		}
+	}
+	sort.Strings(unknown)
+	return append(sorted, unknown...)
+}
+
+// pluralSource returns the source text of a plural category, falling back
+// to the other form for categories English does not use.
+func (u exchangeUnit) pluralSource(category string) string {
+	if text, ok := u.Source[category]; ok {
+		return text
+	}
+	return u.Source[pluralOther]
+}
+
+// value returns the locale file value of the target.
+func (u exchangeUnit) value() interface{} {
+	if !u.Plural {
+		return u.Target[""]
+	}
+	forms := map[string]interface{}{}
+	for form, text := range u.Target {
+		if text != "" {
+			forms[form] = text
+		}
+	}
+	return forms
+}
+
+// sameTranslation tells whether two locale file values hold the same text,
+// ignoring empty plural forms.
+func sameTranslation(a, b interface{}) bool {
+	_, aIsPlural := a.(map[string]interface{})
+	_, bIsPlural := b.(map[string]interface{})
+	if aIsPlural != bIsPlural {
+		return false
+	}
+	nonEmpty := func(value interface{}) map[string]string {
+		forms := map[string]string{}
+		for form, text := range translationForms(value) {
+			if text != "" {
+				forms[form] = text
+			}
+		}
+		return forms
+	}
+	return reflect.DeepEqual(nonEmpty(a), nonEmpty(b))
+}
+
+// mergeExchangeFile merges the translated units of file into the items of a
+// locale file. Items whose text is unchanged keep their exact value and
+// position, new items are appended and items of cleared units are removed.
+// Units whose key is not in source are skipped, and translations that i18n
+// check-plurals would report for locale are rejected. It returns the merged
+// items and the number of changed ones.
+func mergeExchangeFile(items []Item, file *exchangeFile, locale string, source map[string]Translation) ([]Item, int, error) {
+	positions := map[string]int{}
+	for i, item := range items {
+		positions[item.ID] = i
+	}
+	removed := map[string]bool{}
+	changed := 0
+	var rejected []string
+	for _, unit := range file.Units {
+		if _, ok := source[unit.ID]; !ok {
+			continue
+		}
+		if isEmptyTranslation(unit.value()) {
+			i, ok := positions[unit.ID]
+			if !unit.Cleared || !ok || removed[unit.ID] {
+				continue
+			}
+			var existing interface{}
+			if err := json.Unmarshal(items[i].Translation, &existing); err == nil && isEmptyTranslation(existing) {
+				continue
+			}
+			removed[unit.ID] = true
+			changed++
+			continue
+		}
+		value := unit.value()
+		if errs := checkPlurals(locale+".json", source, []Translation{{Id: unit.ID, Translation: value}}); len(errs) > 0 {
+			for _, e := range errs {
+				rejected = append(rejected, fmt.Sprintf("  %s: %s", e.ID, e.Problem))
+			}
+			continue
+		}
+		if i, ok := positions[unit.ID]; ok {
+			var existing interface{}
+			if err := json.Unmarshal(items[i].Translation, &existing); err == nil && sameTranslation(existing, value) {
+				continue
+			}
+		}
+		raw, err := marshalTranslation(value)
+		if err != nil {
+			return nil, 0, err
+		}
+		if i, ok := positions[unit.ID]; ok {
+			items[i].Translation = raw
+			delete(removed, unit.ID)
+		} else {
+			positions[unit.ID] = len(items)
+			items = append(items, Item{ID: unit.ID, Translation: raw})
+		}
+		changed++
+	}
+	if len(rejected) > 0 {
+		return nil, 0, errors.New("invalid plural forms:\n" + strings.Join(rejected, "\n"))
+	}
+	if len(removed) > 0 {
+		kept := items[:0]
+		for _, item := range items {
+			if !removed[item.ID] {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+	return items, changed, nil
+}
+
+func marshalTranslation(value interface{}) (json.RawMessage, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(bytes.TrimSpace(buffer.Bytes())), nil
+}
+
+// detectExchangeFormat guesses the format of an imported file from its name
+// and, for XLIFF, its version attribute.
+func detectExchangeFormat(filename string, data []byte) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".po", ".pot":
+		return exchangeFormatPO, nil
+	case ".xlf", ".xliff", ".xml":
+		version, err := xliffVersion(data)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(version, "2.") {
+			return exchangeFormatXLIFF20, nil
+		}
+		return exchangeFormatXLIFF12, nil
+	}
+	return "", fmt.Errorf("unable to guess the format of %s, please specify it", filename)
+}
+
+func writeExchangeFile(w io.Writer, format string, file *exchangeFile) error {
+	switch format {
+	case exchangeFormatPO:
+		return writePO(w, file)
+	case exchangeFormatXLIFF12:
+		return writeXLIFF12(w, file)
+	case exchangeFormatXLIFF20:
+		return writeXLIFF20(w, file)
+	}
+	return fmt.Errorf("invalid format %q, must be one of: po, xliff, xliff2", format)
+}
+
+func readExchangeFile(data []byte, format string, locale string, source map[string]Translation) (*exchangeFile, error) {
+	switch format {
+	case exchangeFormatPO:
+		return readPO(data, locale)
+	case exchangeFormatXLIFF12:
+		return readXLIFF12(data)
+	case exchangeFormatXLIFF20:
+		return readXLIFF20(data, source)
+	}
+	return nil, fmt.Errorf("invalid format %q, must be one of: po, xliff, xliff2", format)
+}
+
+// checkExchangeLocale fails when the locale declared by an imported file is
+// not locale. Tools write either "pt_BR" or "pt-BR", and the plural forms of
+// PO files are read for the declared locale.
+func checkExchangeLocale(file *exchangeFile, locale string) error {
+	normalize := func(locale string) string {
+		return strings.ToLower(strings.Replace(locale, "_", "-", -1))
+	}
+	if file.Locale != "" && normalize(file.Locale) != normalize(locale) {
+		return fmt.Errorf("file holds %s translations, not %s", file.Locale, locale)
+	}
+	return nil
+}
+
+func exportCmdF(command *cobra.Command, args []string) error {
+	format, err := command.Flags().GetString("format")
+	if err != nil {
+		return errors.New("invalid format parameter")
+	}
+	output, err := command.Flags().GetString("output")
+	if err != nil {
+		return errors.New("invalid output parameter")
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	locale := args[0]
+	source, err := loadTranslations(path.Join(translationDir, baseLocaleFile))
+	if err != nil {
+		return err
+	}
+	var translations []Translation
+	filename := path.Join(translationDir, locale+".json")
+	if _, err = os.Stat(filename); err == nil {
+		if translations, err = loadTranslations(filename); err != nil {
+			return err
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	if err = writeExchangeFile(buffer, format, newExchangeFile(locale, source, translations)); err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(buffer.Bytes())
+		return err
+	}
+	return ioutil.WriteFile(output, buffer.Bytes(), 0644)
+}
+
+func importCmdF(command *cobra.Command, args []string) error {
+	format, err := command.Flags().GetString("format")
+	if err != nil {
+		return errors.New("invalid format parameter")
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	locale, input := args[0], args[1]
+	data, err := ioutil.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		if format, err = detectExchangeFormat(input, data); err != nil {
+			return err
+		}
+	}
+	sourceStrings, err := loadTranslations(path.Join(translationDir, baseLocaleFile))
+	if err != nil {
+		return err
+	}
+	source := translationsByID(sourceStrings)
+	file, err := readExchangeFile(data, format, locale, source)
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %v", input, err)
+	}
+	if err = checkExchangeLocale(file, locale); err != nil {
+		return fmt.Errorf("error importing %s: %v", input, err)
+	}
+
+	filename := path.Join(translationDir, locale+".json")
+	items, err := loadItems(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	items, changed, err := mergeExchangeFile(items, file, locale, source)
+	if err != nil {
+		return fmt.Errorf("error importing %s: %v", input, err)
+	}
+	if changed == 0 {
+		fmt.Printf("%s is up to date\n", filename)
+		return nil
+	}
+	if err = writeLocaleFile(filename, items); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d translations updated\n", filename, changed)
+	return nil
+}
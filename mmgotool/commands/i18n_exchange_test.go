@@ -0,0 +1,311 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchangeRoundTrip(t *testing.T) {
+	source := []Translation{
+		{Id: "app.hello", Translation: "Hello {{.Name}}"},
+		{Id: "app.special", Translation: `Quotes "", <tags> & \backslashes`},
+		{Id: "app.multiline", Translation: "First line\nSecond line\n"},
+		{Id: "app.untranslated", Translation: "Untranslated"},
+		{Id: "app.empty", Translation: "Empty"},
+		{Id: "app.files", Translation: map[string]interface{}{"one": "{{.Count}} file", "other": "{{.Count}} files"}},
+	}
+	translations := []Translation{
+		{Id: "app.hello", Translation: "Witaj {{.Name}}"},
+		{Id: "app.special", Translation: `Cudzysłów "", <znaczniki> & \ukośniki`},
+		{Id: "app.multiline", Translation: "Pierwsza linia\nDruga linia\n"},
+		{Id: "app.empty", Translation: ""},
+		{Id: "app.files", Translation: map[string]interface{}{"one": "{{.Count}} plik", "few": "{{.Count}} pliki", "many": "{{.Count}} plików", "other": "{{.Count}} plików"}},
+		{Id: "app.orphan", Translation: "Sierota"},
+	}
+	items := []Item{
+		{ID: "app.hello", Translation: json.RawMessage(`"Witaj {{.Name}}"`)},
+		{ID: "app.special", Translation: json.RawMessage(`"Cudzysłów \"\", <znaczniki> & \\ukośniki"`)},
+		{ID: "app.multiline", Translation: json.RawMessage(`"Pierwsza linia\nDruga linia\n"`)},
+		{ID: "app.empty", Translation: json.RawMessage(`""`)},
+		{ID: "app.files", Translation: json.RawMessage(`{"one": "{{.Count}} plik", "few": "{{.Count}} pliki", "many": "{{.Count}} plików", "other": "{{.Count}} plików"}`)},
+		{ID: "app.orphan", Translation: json.RawMessage(`"Sierota"`)},
+	}
+	sourceByID := translationsByID(source)
+
+	exported := newExchangeFile("pl", source, translations)
+	assert.Equal(t, []string{"one", "few", "many", "other"}, exported.Categories)
+	assert.Len(t, exported.Units, len(source))
+
+	for _, format := range []string{exchangeFormatPO, exchangeFormatXLIFF12, exchangeFormatXLIFF20} {
+		t.Run(format, func(t *testing.T) {
+			var buffer bytes.Buffer
+			require.NoError(t, writeExchangeFile(&buffer, format, exported))
+
+			imported, err := readExchangeFile(buffer.Bytes(), format, "xx", sourceByID)
+			require.NoError(t, err)
+			assert.Equal(t, "pl", imported.Locale)
+			require.Len(t, imported.Units, len(exported.Units))
+			for i, unit := range imported.Units {
+				assert.Equal(t, exported.Units[i].ID, unit.ID)
+				assert.Equal(t, exported.Units[i].Plural, unit.Plural)
+				assert.True(t, sameTranslation(exported.Units[i].value(), unit.value()), unit.ID)
+			}
+
+			merged, changed, err := mergeExchangeFile(append([]Item(nil), items...), imported, "pl", sourceByID)
+			require.NoError(t, err)
+			assert.Equal(t, 0, changed)
+			assert.Equal(t, items, merged)
+		})
+	}
+}
+
+func TestDetectExchangeFormat(t *testing.T) {
+	for name, test := range map[string]struct {
+		Filename string
+		Data     string
+		Expected string
+	}{
+		"PO":        {"fr.po", "", exchangeFormatPO},
+		"XLIFF 1.2": {"fr.xlf", `<?xml version="1.0"?><xliff version="1.2"></xliff>`, exchangeFormatXLIFF12},
+		"XLIFF 2.0": {"fr.xliff", `<xliff version="2.0"></xliff>`, exchangeFormatXLIFF20},
+	} {
+		t.Run(name, func(t *testing.T) {
+			format, err := detectExchangeFormat(test.Filename, []byte(test.Data))
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, format)
+		})
+	}
+
+	_, err := detectExchangeFormat("fr.csv", nil)
+	assert.EqualError(t, err, "unable to guess the format of fr.csv, please specify it")
+	_, err = detectExchangeFormat("fr.xlf", []byte(`<html></html>`))
+	assert.EqualError(t, err, "not an XLIFF document")
+}
+
+func TestCheckExchangeLocale(t *testing.T) {
+	assert.NoError(t, checkExchangeLocale(&exchangeFile{}, "fr"))
+	assert.NoError(t, checkExchangeLocale(&exchangeFile{Locale: "fr"}, "fr"))
+	assert.NoError(t, checkExchangeLocale(&exchangeFile{Locale: "pt_BR"}, "pt-BR"))
+	assert.EqualError(t, checkExchangeLocale(&exchangeFile{Locale: "de"}, "fr"), "file holds de translations, not fr")
+}
+
+func TestReadPO(t *testing.T) {
+	t.Run("Translation tool output", func(t *testing.T) {
+		file, err := readPO([]byte("\ufeff"+`# Translator comment
+msgid ""
+msgstr ""
+"Language: fr\n"
+"Plural-Forms: nplurals=2; plural=(n > 1);\n"
+
+#: app/a.go
+msgctxt "app.hello"
+msgid "Hello"
+msgstr ""
+"Bon"
+"jour\t!"
+
+#, fuzzy
+msgctxt "app.fuzzy"
+msgid "Fuzzy"
+msgstr "Flou"
+
+msgctxt "app.files"
+msgid "{{.Count}} file"
+msgid_plural "{{.Count}} files"
+msgstr[0] "{{.Count}} fichier"
+msgstr[1] "{{.Count}} fichiers"
+
+#~ msgctxt "app.obsolete"
+#~ msgid "Obsolete"
+#~ msgstr "Obsolète"
+`), "xx")
+		require.NoError(t, err)
+		assert.Equal(t, &exchangeFile{
+			Locale:     "fr",
+			Categories: []string{"one", "other"},
+			Units: []exchangeUnit{
+				{ID: "app.hello", Source: map[string]string{"": "Hello"}, Target: map[string]string{"": "Bonjour\t!"}},
+				{ID: "app.fuzzy", Source: map[string]string{"": "Fuzzy"}, Target: map[string]string{}, Cleared: true},
+				{
+					ID:     "app.files",
+					Plural: true,
+					Source: map[string]string{"one": "{{.Count}} file", "other": "{{.Count}} files"},
+					Target: map[string]string{"one": "{{.Count}} fichier", "other": "{{.Count}} fichiers"},
+				},
+			},
+		}, file)
+	})
+
+	t.Run("Invalid files", func(t *testing.T) {
+		_, err := readPO([]byte("msgid \"Hello\"\nmsgstr \"Bonjour\"\n"), "fr")
+		assert.EqualError(t, err, "line 1: message without msgctxt holding its translation key")
+		_, err = readPO([]byte("msgctxt \"a\"\nmsgid \"A\"\nmsgid_plural \"As\"\nmsgstr[0] \"\"\n"), "fr")
+		assert.EqualError(t, err, "line 1: plural message without a Plural-Forms header")
+		header := "msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=(n != 1);\\n\"\n\n"
+		_, err = readPO([]byte(header+"msgctxt \"a\"\nmsgid \"A\"\nmsgid_plural \"As\"\nmsgstr[5] \"\"\n"), "fr")
+		assert.EqualError(t, err, "line 4: msgstr[5] does not match the plural categories one, other")
+		_, err = readPO([]byte("msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=(n == 1);\\n\"\n"), "fr")
+		assert.EqualError(t, err, `unsupported Plural-Forms "nplurals=2; plural=(n == 1);"`)
+		_, err = readPO([]byte("msgctxt \"a\"\nmsgid \"A\\q\"\n"), "fr")
+		assert.EqualError(t, err, `line 2: invalid escape sequence \q in "A\\q"`)
+	})
+}
+
+func TestGettextPluralForms(t *testing.T) {
+	for language, categories := range cldrPluralCategories {
+		forms, ok := localePluralForms(language)
+		require.True(t, ok, language)
+		target := map[string]string{}
+		for _, category := range forms.Categories {
+			target[category] = category
+		}
+		completePluralForms(target, language)
+		for _, category := range categories {
+			assert.Contains(t, target, category, language)
+		}
+		assert.Len(t, target, len(categories), language)
+	}
+}
+
+func TestPOPluralForms(t *testing.T) {
+	file := &exchangeFile{Locale: "pl", Units: []exchangeUnit{{
+		ID:     "app.files",
+		Plural: true,
+		Source: map[string]string{"one": "{{.Count}} file", "other": "{{.Count}} files"},
+		Target: map[string]string{"one": "{{.Count}} plik", "few": "{{.Count}} pliki", "other": "{{.Count}} plików"},
+	}}}
+	var buffer bytes.Buffer
+	require.NoError(t, writePO(&buffer, file))
+	assert.Contains(t, buffer.String(), `"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"`)
+	assert.Contains(t, buffer.String(), "msgstr[0] \"{{.Count}} plik\"\nmsgstr[1] \"{{.Count}} pliki\"\nmsgstr[2] \"{{.Count}} plików\"\n")
+	assert.NotContains(t, buffer.String(), "msgstr[3]")
+
+	imported, err := readPO(buffer.Bytes(), "pl")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "few", "many", "other"}, imported.Categories)
+	assert.Equal(t, map[string]string{
+		"one":   "{{.Count}} plik",
+		"few":   "{{.Count}} pliki",
+		"many":  "{{.Count}} plików",
+		"other": "{{.Count}} plików",
+	}, imported.Units[0].Target)
+
+	t.Run("Formula indexes are not in CLDR order", func(t *testing.T) {
+		imported, err := readPO([]byte(`msgid ""
+msgstr "Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);\n"
+
+msgctxt "app.files"
+msgid "{{.Count}} file"
+msgid_plural "{{.Count}} files"
+msgstr[0] "{{.Count}} fails"
+msgstr[1] "{{.Count}} faili"
+msgstr[2] "{{.Count}} failu"
+`), "lv")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"one": "{{.Count}} fails", "other": "{{.Count}} faili", "zero": "{{.Count}} failu"}, imported.Units[0].Target)
+	})
+
+	t.Run("Unknown locales have no formula", func(t *testing.T) {
+		file.Locale = "xx"
+		assert.EqualError(t, writePO(&bytes.Buffer{}, file), "no Gettext plural formula known for locale xx")
+	})
+}
+
+func TestMergeExchangeFile(t *testing.T) {
+	items := []Item{
+		{ID: "kept", Translation: json.RawMessage(`"Gardé"`)},
+		{ID: "changed", Translation: json.RawMessage(`"Ancien"`)},
+		{ID: "cleared", Translation: json.RawMessage(`"Périmé"`)},
+		{ID: "untranslated", Translation: json.RawMessage(`"Conservé"`)},
+	}
+	file := &exchangeFile{Units: []exchangeUnit{
+		{ID: "kept", Target: map[string]string{"": "Gardé"}},
+		{ID: "changed", Target: map[string]string{"": "Nouveau <b>"}},
+		{ID: "cleared", Target: map[string]string{"": ""}, Cleared: true},
+		{ID: "untranslated", Target: map[string]string{}},
+		{ID: "missing", Target: map[string]string{}, Cleared: true},
+		{ID: "added", Plural: true, Target: map[string]string{"one": "Un", "other": "Plusieurs"}},
+		{ID: "unknown", Target: map[string]string{"": "Inconnu"}},
+	}}
+	source := map[string]Translation{
+		"kept":         {Id: "kept", Translation: "Kept"},
+		"changed":      {Id: "changed", Translation: "Changed"},
+		"cleared":      {Id: "cleared", Translation: "Cleared"},
+		"untranslated": {Id: "untranslated", Translation: "Untranslated"},
+		"missing":      {Id: "missing", Translation: "Missing"},
+		"added":        {Id: "added", Translation: map[string]interface{}{"one": "One", "other": "Many"}},
+	}
+
+	merged, changed, err := mergeExchangeFile(items, file, "fr", source)
+	require.NoError(t, err)
+	assert.Equal(t, 3, changed)
+	assert.Equal(t, []Item{
+		{ID: "kept", Translation: json.RawMessage(`"Gardé"`)},
+		{ID: "changed", Translation: json.RawMessage(`"Nouveau <b>"`)},
+		{ID: "untranslated", Translation: json.RawMessage(`"Conservé"`)},
+		{ID: "added", Translation: json.RawMessage(`{"one":"Un","other":"Plusieurs"}`)},
+	}, merged)
+
+	t.Run("Invalid plural forms are rejected", func(t *testing.T) {
+		file := &exchangeFile{Units: []exchangeUnit{
+			{ID: "added", Plural: true, Target: map[string]string{"one": "Un"}},
+			{ID: "kept", Plural: true, Target: map[string]string{"one": "Un", "other": "Plusieurs"}},
+			{ID: "changed", Target: map[string]string{"": "Nouveau"}},
+		}}
+		_, _, err := mergeExchangeFile(nil, file, "fr", source)
+		assert.EqualError(t, err, "invalid plural forms:\n"+
+			"  added: missing plural form other\n"+
+			"  kept: translation has plural forms but source is a plain string")
+	})
+}
+
+func TestReadXLIFF20Segments(t *testing.T) {
+	source := map[string]Translation{
+		"app.files": {Id: "app.files", Translation: map[string]interface{}{"one": "{{.Count}} file", "other": "{{.Count}} files"}},
+	}
+	file, err := readXLIFF20([]byte(`<xliff version="2.0" trgLang="fr"><file id="f1">
+<unit id="app.text"><segment id="s1"><source>First. </source><target>Premier. </target></segment><segment id="s2"><source>Second.</source><target>Second.</target></segment></unit>
+<unit id="app.files"><segment id="one"><source>{{.Count}} file</source><target>{{.Count}} fichier</target></segment><segment id="other"><source>{{.Count}} files</source><target>{{.Count}} fichiers</target></segment></unit>
+</file></xliff>`), source)
+	require.NoError(t, err)
+	require.Len(t, file.Units, 2)
+	assert.Equal(t, exchangeUnit{
+		ID:     "app.text",
+		Source: map[string]string{"": "First. Second."},
+		Target: map[string]string{"": "Premier. Second."},
+	}, file.Units[0])
+	assert.True(t, file.Units[1].Plural)
+	assert.Equal(t, map[string]string{"one": "{{.Count}} fichier", "other": "{{.Count}} fichiers"}, file.Units[1].Target)
+
+	_, err = readXLIFF20([]byte(`<xliff version="2.0"><file id="f1">
+<unit id="app.files"><segment id="s1"><source>{{.Count}} files</source></segment></unit>
+</file></xliff>`), source)
+	assert.EqualError(t, err, `app.files: unknown plural category "s1"`)
+}
+
+func TestReadXLIFFClearedTargets(t *testing.T) {
+	file, err := readXLIFF12([]byte(`<xliff version="1.2"><file target-language="fr"><body>
+<trans-unit id="missing"><source>Missing</source></trans-unit>
+<trans-unit id="cleared"><source>Cleared</source><target></target></trans-unit>
+</body></file></xliff>`))
+	require.NoError(t, err)
+	require.Len(t, file.Units, 2)
+	assert.False(t, file.Units[0].Cleared)
+	assert.True(t, file.Units[1].Cleared)
+
+	file, err = readXLIFF20([]byte(`<xliff version="2.0" trgLang="fr"><file id="f1">
+<unit id="missing"><segment><source>Missing</source></segment></unit>
+<unit id="cleared"><segment><source>Cleared</source><target/></segment></unit>
+</file></xliff>`), nil)
+	require.NoError(t, err)
+	require.Len(t, file.Units, 2)
+	assert.False(t, file.Units[0].Cleared)
+	assert.True(t, file.Units[1].Cleared)
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+)
+
+var FmtCmd = &cobra.Command{
+	Use:     "fmt",
+	Short:   "Format locale files",
+	Long:    "Rewrite i18n/en.json and every locale file sorted by key with the canonical indentation, listing the files that changed",
+	Example: "  i18n fmt --check",
+	RunE:    fmtCmdF,
+}
+
+func init() {
+	FmtCmd.Flags().Bool("check", false, "Throw exit code on unformatted locale files instead of rewriting them")
+	addLocaleDirFlags(FmtCmd)
+
+	I18nCmd.AddCommand(FmtCmd)
+}
+
+// formatLocaleFile returns the canonical content of a locale file holding
+// items: entries sorted by key, indented with two spaces, with re-encoded
+// translations keeping the order of their plural forms.
+func formatLocaleFile(items []Item) ([]byte, error) {
+	sorted := make([]Item, 0, len(items))
+	for _, item := range items {
+		translation, err := canonicalTranslation(item.Translation)
+		if err != nil {
+			return nil, fmt.Errorf("invalid translation of %s: %v", item.ID, err)
+		}
+		sorted = append(sorted, Item{ID: item.ID, Translation: translation})
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return JSONMarshal(sorted)
+}
+
+// canonicalTranslation re-encodes a translation value. Unlike a decoded map,
+// it keeps the order of the plural forms.
+func canonicalTranslation(raw json.RawMessage) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		var value interface{}
+		if err = json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return marshalTranslation(value)
+	}
+
+	buffer := &bytes.Buffer{}
+	buffer.WriteByte('{')
+	for decoder.More() {
+		if token, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+		var text interface{}
+		if err = decoder.Decode(&text); err != nil {
+			return nil, err
+		}
+		form, err := marshalTranslation(token)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalTranslation(text)
+		if err != nil {
+			return nil, err
+		}
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		buffer.Write(form)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// writeLocaleFile replaces the content of the locale file filename with the
+// canonical form of items.
+func writeLocaleFile(filename string, items []Item) error {
+	data, err := formatLocaleFile(items)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(filename, data, 0644)
+}
+
+// writeFileAtomically replaces the content of filename through a temporary
+// file synced to disk and renamed over it, so that neither readers nor a
+// crash leave a partial file. A symbolic link is kept, its target is written.
+// The permissions of an existing file are kept, perm applies to new ones.
+func writeFileAtomically(filename string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if fileInfo, err := os.Stat(filename); err == nil {
+		perm = fileInfo.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// writeLocaleDiff writes the unified diff between the content of the file
+// filename and data, nothing when they are equal.
+func writeLocaleDiff(w io.Writer, filename string, data []byte) error {
+	old, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// difflib.SplitLines reports a blank line after the final newline.
+	splitLines := func(text []byte) []string {
+		lines := strings.SplitAfter(string(text), "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		return lines
+	}
+	return difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+		A:        splitLines(old),
+		B:        splitLines(data),
+		FromFile: filename,
+		ToFile:   filename,
+		Context:  3,
+	})
+}
+
+func fmtCmdF(command *cobra.Command, args []string) error {
+	check, err := command.Flags().GetBool("check")
+	if err != nil {
+		return errors.New("invalid check parameter")
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	files, err := getLocaleFiles(translationDir)
+	if err != nil {
+		return err
+	}
+
+	unformatted := 0
+	for _, file := range append([]string{baseLocaleFile}, files...) {
+		filename := path.Join(translationDir, file)
+		data, err2 := readLocaleFile(filename)
+		if err2 != nil {
+			return err2
+		}
+		var items []Item
+		if err2 = json.Unmarshal(data, &items); err2 != nil {
+			return fmt.Errorf("error parsing %s: %v", filename, err2)
+		}
+		formatted, err2 := formatLocaleFile(items)
+		if err2 != nil {
+			return fmt.Errorf("%s: %v", filename, err2)
+		}
+		if bytes.Equal(data, formatted) {
+			continue
+		}
+		unformatted++
+		fmt.Println(filename)
+		if check {
+			continue
+		}
+		if err2 = writeFileAtomically(filename, formatted, 0644); err2 != nil {
+			return err2
+		}
+	}
+	if check && unformatted > 0 {
+		command.SilenceUsage = true
+		return fmt.Errorf("%d locale files are not formatted", unformatted)
+	}
+	return nil
+}
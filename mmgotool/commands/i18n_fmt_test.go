@@ -0,0 +1,115 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatLocaleFile(t *testing.T) {
+	items := []Item{
+		{ID: "b", Translation: json.RawMessage(`"B é <b>"`)},
+		{ID: "a", Translation: json.RawMessage(`{"other": "As",   "one": "A"}`)},
+		{ID: "c", Translation: json.RawMessage(`""`)},
+	}
+	formatted, err := formatLocaleFile(items)
+	require.NoError(t, err)
+	assert.Equal(t, `[
+  {
+    "id": "a",
+    "translation": {
+      "other": "As",
+      "one": "A"
+    }
+  },
+  {
+    "id": "b",
+    "translation": "B é <b>"
+  },
+  {
+    "id": "c",
+    "translation": ""
+  }
+]
+`, string(formatted))
+	assert.Equal(t, "b", items[0].ID, "items are left unchanged")
+
+	var parsed []Item
+	require.NoError(t, json.Unmarshal(formatted, &parsed))
+	again, err := formatLocaleFile(parsed)
+	require.NoError(t, err)
+	assert.Equal(t, string(formatted), string(again))
+
+	formatted, err = formatLocaleFile(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", string(formatted))
+}
+
+func TestWriteFileAtomically(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmgotool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "fr.json")
+
+	require.NoError(t, writeFileAtomically(filename, []byte("new"), 0640))
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	require.NoError(t, os.Chmod(filename, 0600))
+	require.NoError(t, writeFileAtomically(filename, []byte("replaced"), 0644))
+	data, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", string(data))
+	info, err = os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "no temporary file is left behind")
+
+	t.Run("Symbolic links are kept", func(t *testing.T) {
+		link := filepath.Join(dir, "fr-link.json")
+		require.NoError(t, os.Symlink("fr.json", link))
+		require.NoError(t, writeFileAtomically(link, []byte("through link"), 0644))
+		info, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.True(t, info.Mode()&os.ModeSymlink != 0)
+		data, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, "through link", string(data))
+	})
+}
+
+func TestWriteLocaleDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmgotool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "en.json")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("[\n  \"a\",\n  \"b\"\n]\n"), 0600))
+
+	var diff bytes.Buffer
+	require.NoError(t, writeLocaleDiff(&diff, filename, []byte("[\n  \"a\",\n  \"c\"\n]\n")))
+	assert.Equal(t, "--- "+filename+"\n+++ "+filename+`
+@@ -1,4 +1,4 @@
+ [
+   "a",
+-  "b"
++  "c"
+ ]
+`, diff.String())
+
+	diff.Reset()
+	require.NoError(t, writeLocaleDiff(&diff, filename, []byte("[\n  \"a\",\n  \"b\"\n]\n")))
+	assert.Empty(t, diff.String())
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// gitSnapshot writes the files of dir, as committed at ref in the git
+// repository holding dir, to a new temporary folder. The caller removes it.
+func gitSnapshot(dir, ref string) (string, error) {
+	snapshot, err := ioutil.TempDir("", "mmgotool-i18n-")
+	if err != nil {
+		return "", err
+	}
+	// Run from a sub folder of the repository, git archive only writes the
+	// files of that folder, with paths relative to it.
+	cmd := exec.Command("git", "-C", dir, "archive", "--format=tar", ref)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		os.RemoveAll(snapshot)
+		return "", err
+	}
+	if err = cmd.Start(); err != nil {
+		os.RemoveAll(snapshot)
+		return "", fmt.Errorf("unable to read %s at %s: %v", dir, ref, err)
+	}
+	untarErr := untar(stdout, snapshot)
+	// Drain the archive so that git exits on untar errors.
+	io.Copy(ioutil.Discard, stdout)
+	if err = cmd.Wait(); err != nil {
+		os.RemoveAll(snapshot)
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("unable to read %s at %s: %s", dir, ref, message)
+	}
+	if untarErr != nil {
+		os.RemoveAll(snapshot)
+		return "", untarErr
+	}
+	return snapshot, nil
+}
+
+// untar writes the folders and regular files of a tar archive to dir. Other
+// entries, like symbolic links, are skipped.
+func untar(r io.Reader, dir string) error {
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(name, filepath.Clean(dir)+string(filepath.Separator)) {
+			return fmt.Errorf("invalid path %s in archive", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(name, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+				return err
+			}
+			data, err := ioutil.ReadAll(archive)
+			if err != nil {
+				return err
+			}
+			if err = ioutil.WriteFile(name, data, 0644); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkBase is the state of the source code at the base ref of i18n check
+// --since, against which the current state is compared.
+type checkBase struct {
+	// Keys are the extracted translation keys.
+	Keys map[string]bool
+	// SourceKeys are the keys of en.json.
+	SourceKeys map[string]bool
+	// UnmatchedDynamicKeys are the dynamic key entries matching no key.
+	UnmatchedDynamicKeys map[string]bool
+	// Calls identifies the dynamic and rejected calls by callID.
+	Calls map[string]bool
+}
+
+// baseScanCacheDirName is the folder of the scan cache of the base
+// revisions, inside the scan cache folder.
+const baseScanCacheDirName = "since"
+
+// loadCheckBase extracts the translation keys of the source code at ref.
+// Only the Mattermost, or Customer Portal, folder is read at ref, the
+// enterprise folder is used as is.
+func loadCheckBase(opts *extractOptions, ref string) (*checkBase, error) {
+	root := opts.MattermostDir
+	if opts.PortalDir != "" {
+		root = opts.PortalDir
+	}
+	snapshot, err := gitSnapshot(root, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(snapshot)
+
+	baseOpts := *opts
+	baseOpts.TranslationDir = snapshot
+	// The base has a cache of its own, so that it does not evict the
+	// summaries of the working tree.
+	if opts.CacheDir != "" {
+		baseOpts.CacheDir = filepath.Join(opts.CacheDir, baseScanCacheDirName)
+	}
+	if opts.PortalDir != "" {
+		baseOpts.PortalDir = snapshot
+	} else {
+		baseOpts.MattermostDir = snapshot
+	}
+	srcStrings, err := getBaseFileSrcStrings(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("at %s: %v", ref, err)
+	}
+	base := &checkBase{
+		Keys:                 map[string]bool{},
+		SourceKeys:           map[string]bool{},
+		UnmatchedDynamicKeys: map[string]bool{},
+		Calls:                map[string]bool{},
+	}
+	var baseFileList []string
+	for _, t := range srcStrings {
+		base.SourceKeys[t.Id] = true
+		baseFileList = append(baseFileList, t.Id)
+	}
+	sort.Strings(baseFileList)
+
+	extracted, dynamicKeys, err := extractUsages(&baseOpts, baseFileList)
+	if err != nil {
+		return nil, fmt.Errorf("at %s: %v", ref, err)
+	}
+	for key := range extracted.Usages {
+		base.Keys[key] = true
+	}
+	for _, key := range unmatchedDynamicKeys(dynamicKeys, baseFileList) {
+		base.UnmatchedDynamicKeys[key.Entry] = true
+	}
+	for _, call := range extracted.DynamicCalls {
+		base.Calls[callID(snapshot, call.File, call.Func, call.Key)] = true
+	}
+	for _, call := range extracted.RejectedCalls {
+		base.Calls[callID(snapshot, call.File, call.Func, call.Reason)] = true
+	}
+	return base, nil
+}
+
+// callID identifies a call across the base and current source code by its
+// file relative to root, leaving out the line, along with details of the
+// call.
+func callID(root, file string, details ...string) string {
+	if rel, err := filepath.Rel(root, file); err == nil && !strings.HasPrefix(rel, "..") {
+		file = filepath.ToSlash(rel)
+	}
+	return strings.Join(append([]string{file}, details...), "\x00")
+}
+
+// missingKey returns whether key was already used in the source code and
+// missing from en.json at the base ref.
+func (b *checkBase) missingKey(key string) bool {
+	return b.Keys[key] && !b.SourceKeys[key]
+}
+
+// unusedKey returns whether key was already in en.json and unused in the
+// source code at the base ref.
+func (b *checkBase) unusedKey(key string) bool {
+	return b.SourceKeys[key] && !b.Keys[key]
+}
+
+// reportUnrelatedSourceChanges reports the keys added to or removed from
+// en.json since the base ref although their use in the source code did not
+// change. sourceKeys are the keys of en.json and usages those of the source
+// code. Changes leaving en.json out of date are reported as added or removed
+// keys instead.
+func reportUnrelatedSourceChanges(r *reporter, base *checkBase, sourceKeys map[string]bool, usages i18nUsages, baseFile string) {
+	var keys []string
+	for key := range sourceKeys {
+		if !base.SourceKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	for key := range base.SourceKeys {
+		if !sourceKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, used := usages[key]; used != base.Keys[key] || used != sourceKeys[key] {
+			continue
+		}
+		text, message := "Unrelated addition: "+key, "translation key added to "+baseLocaleFile+" without a matching source code change"
+		line := r.keyLine(baseFile, key)
+		if !sourceKeys[key] {
+			text, message = "Unrelated removal: "+key, "translation key removed from "+baseLocaleFile+" without a matching source code change"
+			line = 0
+		}
+		r.report(text, finding{
+			RuleID:   "unrelated-source-change",
+			Severity: severityWarning,
+			Key:      key,
+			File:     baseFile,
+			Line:     line,
+			Message:  message,
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestLoadCheckBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := writeSourceTree(t, map[string]string{
+		"README.md": "The server is in a sub folder.\n",
+		"server/app/a.go": `package app
+
+func f() {
+	c.T("app.kept")
+	c.T("app.removed")
+	c.T("app.missing")
+	c.T(key)
+}
+`,
+		"server/i18n/en.json": `[
+  {"id": "app.kept", "translation": "Kept"},
+  {"id": "app.removed", "translation": "Removed"},
+  {"id": "app.unused", "translation": "Unused"}
+]`,
+	})
+	defer os.RemoveAll(repo)
+	git(t, repo, "init", "-q")
+	git(t, repo, "add", "-A")
+	git(t, repo, "commit", "-q", "-m", "base")
+
+	dir := filepath.Join(repo, "server")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app/a.go"), []byte(`package app
+
+func f() {
+	c.T("app.kept")
+	c.T("app.added")
+	c.T(key)
+}
+`), 0600))
+
+	cacheDir, err := ioutil.TempDir("", "mmgotool")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+	opts := &extractOptions{MattermostDir: dir, TranslationDir: dir, SkipDynamic: true, Config: defaultI18nConfig(), CacheDir: cacheDir}
+	base, err := loadCheckBase(opts, "HEAD")
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(cacheDir, baseScanCacheDirName, scanCacheFileName))
+	assert.NoFileExists(t, filepath.Join(cacheDir, scanCacheFileName), "the cache of the working tree is untouched")
+	assert.Equal(t, map[string]bool{"app.kept": true, "app.removed": true, "app.missing": true}, base.Keys)
+	assert.Equal(t, map[string]bool{"app.kept": true, "app.removed": true, "app.unused": true}, base.SourceKeys)
+	assert.Equal(t, map[string]bool{callID(dir, filepath.Join(dir, "app/a.go"), "c.T", "key"): true}, base.Calls)
+
+	assert.True(t, base.missingKey("app.missing"))
+	assert.False(t, base.missingKey("app.added"))
+	assert.True(t, base.unusedKey("app.unused"))
+	assert.False(t, base.unusedKey("app.removed"))
+
+	_, err = loadCheckBase(opts, "unknown-ref")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to read "+dir+" at unknown-ref")
+}
+
+func TestReportUnrelatedSourceChanges(t *testing.T) {
+	base := &checkBase{
+		Keys:       map[string]bool{"app.used": true, "app.dropped": true, "app.stale": true},
+		SourceKeys: map[string]bool{"app.used": true, "app.dropped": true, "app.stale": true, "app.unused": true},
+	}
+	sourceKeys := map[string]bool{"app.used": true, "app.new": true, "app.unrelated": true, "app.stale": true, "app.fixed": true}
+	usages := i18nUsages{"app.used": nil, "app.new": nil, "app.stale": nil, "app.fixed": nil}
+	base.Keys["app.fixed"] = true
+
+	var out bytes.Buffer
+	r := &reporter{format: formatText, out: &out, lines: map[string]map[string]int{}}
+	reportUnrelatedSourceChanges(r, base, sourceKeys, usages, "i18n/en.json")
+	require.NoError(t, r.flush())
+	// app.new and app.dropped follow the source code, app.fixed and
+	// app.unused were out of date at the base ref and app.unrelated is left
+	// to the removed key check.
+	assert.Equal(t, "Unrelated addition: app.fixed\nUnrelated removal: app.unused\n", out.String())
+}
+
+func TestUntar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmgotool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	archive := func(names ...string) *bytes.Buffer {
+		var buffer bytes.Buffer
+		w := tar.NewWriter(&buffer)
+		for _, name := range names {
+			require.NoError(t, w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(name)), Typeflag: tar.TypeReg}))
+			_, err := w.Write([]byte(name))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.WriteHeader(&tar.Header{Name: "link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink}))
+		require.NoError(t, w.Close())
+		return &buffer
+	}
+
+	require.NoError(t, untar(archive("app/a.go"), dir))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app/a.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "app/a.go", string(data))
+	_, err = os.Lstat(filepath.Join(dir, "link"))
+	assert.True(t, os.IsNotExist(err))
+
+	err = untar(archive("../escaped.go"), dir)
+	require.Error(t, err)
+	assert.Equal(t, "invalid path ../escaped.go in archive", err.Error())
+}
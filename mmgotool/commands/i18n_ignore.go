@@ -0,0 +1,131 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const i18nIgnoreFileName = ".i18nignore"
+
+// defaultIgnorePatterns are applied to every scanned folder on top of its
+// .i18nignore file.
+var defaultIgnorePatterns = []string{
+	".git/",
+	"/vendor/",
+	"*_test.go",
+	"model/client4.go",
+}
+
+// ignorePattern is a line of an .i18nignore file. Patterns use the path.Match
+// syntax and are matched against the trailing elements of the slash
+// separated path relative to the scanned folder, so "*_test.go" matches test
+// files anywhere and "model/client4.go" any file of a model folder. A leading
+// slash anchors the pattern to the scanned folder and a trailing slash only
+// matches folders.
+type ignorePattern struct {
+	Pattern  string
+	Anchored bool
+	DirOnly  bool
+}
+
+func parseIgnorePattern(line string) (ignorePattern, error) {
+	p := ignorePattern{Pattern: line}
+	if strings.HasSuffix(p.Pattern, "/") {
+		p.DirOnly = true
+		p.Pattern = strings.TrimSuffix(p.Pattern, "/")
+	}
+	if strings.HasPrefix(p.Pattern, "/") {
+		p.Anchored = true
+		p.Pattern = strings.TrimPrefix(p.Pattern, "/")
+	}
+	if p.Pattern == "" {
+		return p, fmt.Errorf("invalid pattern %q", line)
+	}
+	if _, err := path.Match(p.Pattern, ""); err != nil {
+		return p, fmt.Errorf("invalid pattern %q: %v", line, err)
+	}
+	return p, nil
+}
+
+func (p ignorePattern) match(elems []string, isDir bool) bool {
+	if p.DirOnly && !isDir {
+		return false
+	}
+	n := strings.Count(p.Pattern, "/") + 1
+	if len(elems) < n || (p.Anchored && len(elems) != n) {
+		return false
+	}
+	matched, _ := path.Match(p.Pattern, strings.Join(elems[len(elems)-n:], "/"))
+	return matched
+}
+
+// ignoreRules tells which paths of a scanned folder are skipped.
+type ignoreRules struct {
+	root     string
+	patterns []ignorePattern
+}
+
+// loadIgnoreRules returns the default rules and those of the .i18nignore
+// file of root, if any. Empty lines and lines starting with # are skipped.
+func loadIgnoreRules(root string) (*ignoreRules, error) {
+	rules := &ignoreRules{root: root}
+	for _, line := range defaultIgnorePatterns {
+		p, _ := parseIgnorePattern(line)
+		rules.patterns = append(rules.patterns, p)
+	}
+
+	filename := filepath.Join(root, i18nIgnoreFileName)
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return rules, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := parseIgnorePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, lineNumber, err)
+		}
+		rules.patterns = append(rules.patterns, p)
+	}
+	return rules, scanner.Err()
+}
+
+// contains tells whether filename is inside the root of the rules.
+func (r *ignoreRules) contains(filename string) bool {
+	rel, err := filepath.Rel(r.root, filename)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ignored tells whether filename, found in the root of the rules, is
+// skipped, either directly or because one of its folders is.
+func (r *ignoreRules) ignored(filename string, isDir bool) bool {
+	rel, err := filepath.Rel(r.root, filename)
+	if err != nil || rel == "." {
+		return false
+	}
+	elems := strings.Split(filepath.ToSlash(rel), "/")
+	for i := 1; i <= len(elems); i++ {
+		for _, p := range r.patterns {
+			if p.match(elems[:i], isDir || i < len(elems)) {
+				return true
+			}
+		}
+	}
+	return false
+}
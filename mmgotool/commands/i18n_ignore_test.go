@@ -0,0 +1,137 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		i18nIgnoreFileName: `# Generated mocks
+mocks/
+/tools/*.go
+*_gen.go
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	rules, err := loadIgnoreRules(dir)
+	require.NoError(t, err)
+
+	for name, ignored := range map[string]bool{
+		"app/app.go":              false,
+		"app/app_test.go":         true,
+		"model/client4.go":        true,
+		"server/model/client4.go": true,
+		"vendor/pkg/a.go":         true,
+		"app/vendor/a.go":         false,
+		".git/hooks/a.go":         true,
+		"app/mocks/store.go":      true,
+		"app/mocks.go":            false,
+		"tools/main.go":           true,
+		"app/tools/main.go":       false,
+		"tools/sub/main.go":       false,
+		"store/layer_gen.go":      true,
+	} {
+		assert.Equal(t, ignored, rules.ignored(filepath.Join(dir, name), false), name)
+	}
+	assert.True(t, rules.ignored(filepath.Join(dir, "app/mocks"), true))
+	assert.False(t, rules.ignored(dir, true))
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		dir := writeSourceTree(t, map[string]string{
+			i18nIgnoreFileName: "app/\n[\n",
+		})
+		defer os.RemoveAll(dir)
+
+		_, err := loadIgnoreRules(dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), i18nIgnoreFileName+":2: invalid pattern \"[\"")
+	})
+}
+
+func TestExtractSrcStringsSkippedFiles(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		i18nIgnoreFileName: "mocks/\n",
+		"app/a.go": `package app
+
+func f() {
+	c.T("app.kept")
+	c.T(generatedKey)
+}
+`,
+		"app/mocks/store.go": `package mocks
+
+func f() {
+	c.T("app.mock")
+}
+`,
+		"app/store_gen.go": `// Code generated by layer_generators. DO NOT EDIT.
+
+package app
+
+const generatedKey = "app.generated_const"
+
+func g() {
+	c.T("app.generated")
+}
+`,
+		"app/tagged.go": `//go:build enterprise_only
+
+package app
+
+func h() {
+	c.T("app.tagged")
+}
+`,
+		"app/a_windows.go": `package app
+
+func i() {
+	c.T("app.windows")
+}
+`,
+		"app/darwin.go": `//go:build darwin
+
+package app
+
+func j() {
+	c.T("app.darwin")
+}
+`,
+		"app/not_unix.go": `//go:build !unix && enterprise_only
+
+package app
+
+func k() {
+	c.T("app.not_unix")
+}
+`,
+		"app/impossible_linux.go": `//go:build windows
+
+package app
+
+func l() {
+	c.T("app.impossible")
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	opts := &extractOptions{MattermostDir: dir, TranslationDir: dir, SkipDynamic: true, Config: defaultI18nConfig()}
+	result, err := extractSrcStrings(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.darwin", "app.generated_const", "app.kept", "app.windows"}, result.Usages.keys())
+	assert.Empty(t, result.DynamicCalls)
+
+	opts.BuildTags = []string{"enterprise_only"}
+	result, err = extractSrcStrings(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.darwin", "app.generated_const", "app.kept", "app.not_unix", "app.tagged", "app.windows"}, result.Usages.keys())
+}
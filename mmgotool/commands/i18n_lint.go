@@ -0,0 +1,179 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var LintCmd = &cobra.Command{
+	Use:     "lint",
+	Short:   "Check translation key naming conventions",
+	Long:    "Check that the keys of i18n/en.json and of the translation calls follow the naming conventions configured in the lint section of " + i18nConfigFileName,
+	Example: "  i18n lint --format sarif",
+	RunE:    lintCmdF,
+}
+
+func init() {
+	addExtractFlags(LintCmd)
+	addFormatFlag(LintCmd)
+
+	I18nCmd.AddCommand(LintCmd)
+}
+
+// lintRules are the ids of the rules checked by i18n lint.
+var lintRules = map[string]bool{
+	"key-pattern":      true,
+	"app-error-suffix": true,
+	"enterprise-key":   true,
+	"package-prefix":   true,
+}
+
+// keyLinter checks translation keys against the naming conventions.
+type keyLinter struct {
+	config        lintConfig
+	keyPattern    *regexp.Regexp
+	enterpriseDir string
+	disabled      map[string]bool
+}
+
+func newKeyLinter(config lintConfig, enterpriseDir string) (*keyLinter, error) {
+	keyPattern, err := regexp.Compile(config.KeyPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lint key_pattern: %v", err)
+	}
+	l := &keyLinter{config: config, keyPattern: keyPattern, disabled: map[string]bool{}}
+	if enterpriseDir != "" {
+		if l.enterpriseDir, err = filepath.Abs(enterpriseDir); err != nil {
+			return nil, err
+		}
+	}
+	for _, rule := range config.DisabledRules {
+		l.disabled[rule] = true
+	}
+	return l, nil
+}
+
+// lintKey checks a key of en.json.
+func (l *keyLinter) lintKey(id string) []finding {
+	if l.disabled["key-pattern"] || l.keyPattern.MatchString(id) {
+		return nil
+	}
+	return []finding{{
+		RuleID:   "key-pattern",
+		Severity: severityError,
+		Key:      id,
+		Message:  fmt.Sprintf("key does not match %s", l.config.KeyPattern),
+	}}
+}
+
+// lintCall checks the key of a translation call.
+func (l *keyLinter) lintCall(call translationCall) []finding {
+	var findings []finding
+	add := func(rule, message string) {
+		if !l.disabled[rule] {
+			findings = append(findings, finding{RuleID: rule, Severity: severityError, Key: call.ID, Message: message}.at(call.keyLocation))
+		}
+	}
+
+	funcName := call.Func[strings.LastIndex(call.Func, ".")+1:]
+	for _, name := range l.config.AppErrorFunctions {
+		if name == funcName && !strings.HasSuffix(call.ID, l.config.AppErrorSuffix) {
+			add("app-error-suffix", fmt.Sprintf("key passed to %s does not end with %s", funcName, l.config.AppErrorSuffix))
+		}
+	}
+
+	key := call.ID
+	if l.inEnterpriseDir(call.File) {
+		key = strings.TrimPrefix(key, enterpriseKeyPrefix)
+	} else if strings.HasPrefix(key, enterpriseKeyPrefix) {
+		add("enterprise-key", "enterprise key used outside of the enterprise source code")
+	}
+
+	prefixes, ok := l.config.PackagePrefixes[call.Package]
+	if !ok {
+		return findings
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return findings
+		}
+	}
+	add("package-prefix", fmt.Sprintf("key used in package %s does not start with %s", call.Package, strings.Join(prefixes, " or ")))
+	return findings
+}
+
+func (l *keyLinter) inEnterpriseDir(filename string) bool {
+	if l.enterpriseDir == "" {
+		return false
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return false
+	}
+	return abs == l.enterpriseDir || strings.HasPrefix(abs, l.enterpriseDir+string(filepath.Separator))
+}
+
+func lintCmdF(command *cobra.Command, args []string) error {
+	r, err := newReporter(command)
+	if err != nil {
+		return err
+	}
+	opts, err := getExtractOptions(command)
+	if err != nil {
+		return err
+	}
+	linter, err := newKeyLinter(opts.Config.Lint, opts.EnterpriseDir)
+	if err != nil {
+		return err
+	}
+	sourceStrings, err := getBaseFileSrcStrings(opts.TranslationDir)
+	if err != nil {
+		return err
+	}
+	extracted, err := extractSrcStrings(opts)
+	if err != nil {
+		command.SilenceUsage = true
+		return err
+	}
+
+	count := 0
+	report := func(findings []finding) {
+		for _, f := range findings {
+			location := f.File
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.File, f.Line)
+			}
+			r.report(fmt.Sprintf("%s: %s: %s", location, f.Key, f.Message), f)
+			count++
+		}
+	}
+	filename := path.Join(opts.TranslationDir, "i18n", baseLocaleFile)
+	for _, t := range sourceStrings {
+		findings := linter.lintKey(t.Id)
+		for i := range findings {
+			findings[i].File = filename
+			findings[i].Line = r.keyLine(filename, t.Id)
+		}
+		report(findings)
+	}
+	for _, call := range extracted.Calls {
+		report(linter.lintCall(call))
+	}
+	if err = r.flush(); err != nil {
+		return err
+	}
+	if count > 0 {
+		command.SilenceUsage = true
+		return errors.New("translation key naming violations found")
+	}
+	return nil
+}
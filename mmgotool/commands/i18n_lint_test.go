@@ -0,0 +1,145 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyLinterLintKey(t *testing.T) {
+	linter, err := newKeyLinter(defaultI18nConfig().Lint, "")
+	require.NoError(t, err)
+
+	for _, id := range []string{"app.user.save.app_error", "api.command_2.hint", "January"} {
+		assert.Empty(t, linter.lintKey(id), id)
+	}
+	for _, id := range []string{"app", "app.User.save", "app..save", "app.user save", "app.user-save", "JANUARY", "January.name"} {
+		findings := linter.lintKey(id)
+		require.Len(t, findings, 1, id)
+		assert.Equal(t, "key-pattern", findings[0].RuleID)
+	}
+
+	config := defaultI18nConfig().Lint
+	config.DisabledRules = []string{"key-pattern"}
+	linter, err = newKeyLinter(config, "")
+	require.NoError(t, err)
+	assert.Empty(t, linter.lintKey("Invalid Key"))
+}
+
+func TestKeyLinterLintCall(t *testing.T) {
+	enterpriseDir := filepath.Join("testdata", "enterprise")
+	config := defaultI18nConfig().Lint
+	config.PackagePrefixes = map[string][]string{"api4": {"api."}, "app": {"app."}, "ldap": {"ldap."}}
+	linter, err := newKeyLinter(config, enterpriseDir)
+	require.NoError(t, err)
+
+	call := func(file, pkg, fn, id string) translationCall {
+		return translationCall{keyLocation: keyLocation{File: file, Line: 3}, ID: id, Func: fn, Package: pkg}
+	}
+	rules := func(findings []finding) []string {
+		var ids []string
+		for _, f := range findings {
+			ids = append(ids, f.RuleID)
+		}
+		return ids
+	}
+	enterpriseFile := filepath.Join(enterpriseDir, "ldap", "ldap.go")
+
+	for name, test := range map[string]struct {
+		Call     translationCall
+		Expected []string
+	}{
+		"Valid key":                      {call("app/user.go", "app", "c.T", "app.user.save"), nil},
+		"Configured package prefix":      {call("api4/user.go", "api4", "c.T", "api.user.save"), nil},
+		"Package name is not a prefix":   {call("api4/user.go", "api4", "c.T", "api4.user.save"), []string{"package-prefix"}},
+		"Wrong package prefix":           {call("app/user.go", "app", "c.T", "model.user.save"), []string{"package-prefix"}},
+		"Application error":              {call("app/user.go", "app", "model.NewAppError", "app.user.save.app_error"), nil},
+		"Application error suffix":       {call("app/user.go", "app", "model.NewAppError", "app.user.save"), []string{"app-error-suffix"}},
+		"Enterprise key":                 {call(enterpriseFile, "ldap", "c.T", "ent.ldap.bind"), nil},
+		"Enterprise key outside":         {call("app/user.go", "app", "c.T", "ent.app.bind"), []string{"enterprise-key", "package-prefix"}},
+		"Enterprise key wrong package":   {call(enterpriseFile, "ldap", "c.T", "ent.saml.bind"), []string{"package-prefix"}},
+		"Unknown package is not linted":  {call("app/user.go", "", "c.T", "model.user.save"), nil},
+		"Unlisted package is not linted": {call("model/user.go", "model", "c.T", "app.user.save"), nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, rules(linter.lintCall(test.Call)))
+		})
+	}
+
+	t.Run("Package prefixes are not checked by default", func(t *testing.T) {
+		linter, err := newKeyLinter(defaultI18nConfig().Lint, enterpriseDir)
+		require.NoError(t, err)
+		assert.Empty(t, linter.lintCall(call("api4/user.go", "api4", "c.T", "api.user.save")))
+		assert.Empty(t, linter.lintCall(call("app/user.go", "app", "c.T", "model.user.save")))
+	})
+
+	t.Run("Findings are located at the call", func(t *testing.T) {
+		findings := linter.lintCall(call("app/user.go", "app", "c.T", "model.user.save"))
+		assert.Equal(t, []finding{{
+			RuleID:   "package-prefix",
+			Severity: severityError,
+			Key:      "model.user.save",
+			File:     "app/user.go",
+			Line:     3,
+			Message:  "key used in package app does not start with app.",
+		}}, findings)
+	})
+}
+
+func TestLintServerKeys(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"i18n/en.json": `[
+  {"id": "April", "translation": "April"},
+  {"id": "January", "translation": "January"},
+  {"id": "api.admin.add_certificate.array.app_error", "translation": "No file under 'certificate' in request."},
+  {"id": "api.command_away.desc", "translation": "Set your status away"},
+  {"id": "api.context.404.app_error", "translation": "Sorry, we could not find the page."},
+  {"id": "api.post.check_for_out_of_channel_mentions.message.multiple", "translation": "@{{.Usernames}} and @{{.LastUsername}} did not get notified by this mention because they are not in the channel."},
+  {"id": "api.user.create_user.signup_email_disabled.app_error", "translation": "User sign-up with email is disabled."},
+  {"id": "app.import.import_line.unknown_line_type.error", "translation": "Import data line has unknown type \"{{.Type}}\"."},
+  {"id": "ent.ldap.do_login.invalid_password.app_error", "translation": "Invalid password."},
+  {"id": "model.user.is_valid.pwd_lowercase.app_error", "translation": "Your password must contain at least {{.Min}} characters made up of at least one lowercase letter."},
+  {"id": "store.sql_channel.save.archived_channel.app_error", "translation": "You can not modify an archived channel."},
+  {"id": "web.incoming_webhook.text.app_error", "translation": "No text specified."}
+]
+`,
+		"api4/user.go": `package api4
+
+func createUser(c *Context) {
+	c.Err = model.NewAppError("createUser", "api.user.create_user.signup_email_disabled.app_error", nil, "", 501)
+	c.T("api.command_away.desc")
+	c.T("January")
+}
+`,
+		"store/channel.go": `package store
+
+func save() {
+	model.NewAppError("SqlChannelStore.Save", "store.sql_channel.save.archived_channel.app_error", nil, "", 400)
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	linter, err := newKeyLinter(defaultI18nConfig().Lint, "")
+	require.NoError(t, err)
+	sourceStrings, err := getBaseFileSrcStrings(dir)
+	require.NoError(t, err)
+	require.Len(t, sourceStrings, 12)
+	for _, s := range sourceStrings {
+		assert.Empty(t, linter.lintKey(s.Id), s.Id)
+	}
+
+	opts := &extractOptions{MattermostDir: dir, TranslationDir: dir, SkipDynamic: true, Config: defaultI18nConfig()}
+	extracted, err := extractSrcStrings(opts)
+	require.NoError(t, err)
+	require.Len(t, extracted.Calls, 4)
+	for _, call := range extracted.Calls {
+		assert.Empty(t, linter.lintCall(call), call.ID)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const baseLocaleFile = "en.json"
+
+func addLocaleDirFlags(command *cobra.Command) {
+	command.Flags().String("portal-dir", "../customer-web-server", "Path to folder with the Mattermost Customer Portal source code")
+	command.Flags().String("enterprise-dir", "../enterprise", "Path to folder with the Mattermost enterprise source code")
+	command.Flags().String("mattermost-dir", "./", "Path to folder with the Mattermost source code")
+}
+
+// getLocaleDir returns the folder holding the locale files selected by the
+// flags added with addLocaleDirFlags.
+func getLocaleDir(command *cobra.Command) (string, error) {
+	enterpriseDir, err := command.Flags().GetString("enterprise-dir")
+	if err != nil {
+		return "", errors.New("invalid enterprise-dir parameter")
+	}
+	mattermostDir, err := command.Flags().GetString("mattermost-dir")
+	if err != nil {
+		return "", errors.New("invalid mattermost-dir parameter")
+	}
+	portalDir, err := command.Flags().GetString("portal-dir")
+	if err != nil {
+		return "", errors.New("invalid portal-dir parameter")
+	}
+	translationDir := path.Join(mattermostDir, "i18n")
+	if portalDir != "" {
+		if enterpriseDir != "" || mattermostDir != "" {
+			return "", errors.New("please specify EITHER portal-dir or enterprise-dir/mattermost-dir")
+		}
+		translationDir = portalDir
+	}
+	return translationDir, nil
+}
+
+// getLocaleConfig loads the configuration from the file given with the
+// config flag or from the source code folder selected by the flags added with
+// addLocaleDirFlags.
+func getLocaleConfig(command *cobra.Command) (*i18nConfig, error) {
+	configPath, err := command.Flags().GetString("config")
+	if err != nil {
+		return nil, errors.New("invalid config parameter")
+	}
+	rootDir, err := command.Flags().GetString("mattermost-dir")
+	if err != nil {
+		return nil, errors.New("invalid mattermost-dir parameter")
+	}
+	portalDir, err := command.Flags().GetString("portal-dir")
+	if err != nil {
+		return nil, errors.New("invalid portal-dir parameter")
+	}
+	if portalDir != "" {
+		rootDir = portalDir
+	}
+	return loadI18nConfig(rootDir, configPath)
+}
+
+// getLocaleFiles returns the sorted names of the shipped locale files, every
+// JSON file in localeDir other than the en.json base file.
+func getLocaleFiles(localeDir string) ([]string, error) {
+	files, err := ioutil.ReadDir(localeDir)
+	if err != nil {
+		return nil, err
+	}
+	var shippedFiles []string
+	for _, file := range files {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" && file.Name() != baseLocaleFile {
+			shippedFiles = append(shippedFiles, file.Name())
+		}
+	}
+	sort.Strings(shippedFiles)
+	return shippedFiles, nil
+}
+
+// localeFileError lists the problems of an invalid locale file.
+type localeFileError struct {
+	File     string
+	Problems []string
+}
+
+func (e *localeFileError) Error() string {
+	if len(e.Problems) == 1 {
+		return fmt.Sprintf("error parsing %s: %s", e.File, e.Problems[0])
+	}
+	lines := []string{fmt.Sprintf("error parsing %s:", e.File)}
+	for _, problem := range e.Problems {
+		lines = append(lines, "  "+problem)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validateLocaleFile checks that data is a list of entries, each with a
+// unique id and a translation that is either a string or an object of plural
+// forms. Every problem is reported along with its line.
+func validateLocaleFile(filename string, data []byte) error {
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+	syntaxError := func(err error) error {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			return &localeFileError{File: filename, Problems: []string{fmt.Sprintf("line %d: %v", lineAt(syntaxErr.Offset), err)}}
+		}
+		return &localeFileError{File: filename, Problems: []string{err.Error()}}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil {
+		return syntaxError(err)
+	} else if token != json.Delim('[') {
+		return &localeFileError{File: filename, Problems: []string{"not a list of translations"}}
+	}
+	var problems []string
+	lines := map[string]int{}
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return syntaxError(err)
+		}
+		line := lineAt(decoder.InputOffset() - int64(len(raw)))
+		var entry map[string]interface{}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: entry is not an object", line))
+			continue
+		}
+		id, _ := entry["id"].(string)
+		if id == "" {
+			problems = append(problems, fmt.Sprintf("line %d: entry without id", line))
+			continue
+		}
+		if first, ok := lines[id]; ok {
+			problems = append(problems, fmt.Sprintf("line %d: duplicate id %s, first defined on line %d", line, id, first))
+		} else {
+			lines[id] = line
+		}
+		if !isValidTranslation(entry["translation"]) {
+			problems = append(problems, fmt.Sprintf("line %d: translation of %s is neither a string nor an object of plural forms", line, id))
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return syntaxError(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		problems = append(problems, fmt.Sprintf("line %d: unexpected data after the list of translations", lineAt(decoder.InputOffset())))
+	}
+	if len(problems) > 0 {
+		return &localeFileError{File: filename, Problems: problems}
+	}
+	return nil
+}
+
+// isValidTranslation tells whether value is a string or an object whose
+// values are strings.
+func isValidTranslation(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return true
+	case map[string]interface{}:
+		for _, text := range v {
+			if _, ok := text.(string); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// readLocaleFile reads and validates a locale file.
+func readLocaleFile(filename string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateLocaleFile(filename, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func loadTranslations(filename string) ([]Translation, error) {
+	data, err := readLocaleFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var translations []Translation
+	if err = json.Unmarshal(data, &translations); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", filename, err)
+	}
+	return translations, nil
+}
+
+// loadItems reads the entries of a locale file, keeping their translation
+// values as they are written.
+func loadItems(filename string) ([]Item, error) {
+	data, err := readLocaleFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	if err = json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", filename, err)
+	}
+	return items, nil
+}
+
+// translationsByID indexes translations by their id.
+func translationsByID(translations []Translation) map[string]Translation {
+	byID := make(map[string]Translation, len(translations))
+	for _, t := range translations {
+		byID[t.Id] = t
+	}
+	return byID
+}
+
+// translationForms returns the strings of a translation value. Plain strings
+// are returned under the "" form, plural objects under their plural category.
+func translationForms(value interface{}) map[string]string {
+	forms := map[string]string{}
+	switch v := value.(type) {
+	case string:
+		forms[""] = v
+	case map[string]interface{}:
+		for form, text := range v {
+			if s, ok := text.(string); ok {
+				forms[form] = s
+			}
+		}
+	}
+	return forms
+}
+
+// isEmptyTranslation tells whether a translation value holds no text at all.
+func isEmptyTranslation(value interface{}) bool {
+	for _, text := range translationForms(value) {
+		if text != "" {
+			return false
+		}
+	}
+	return true
+}
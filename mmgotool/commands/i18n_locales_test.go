@@ -0,0 +1,71 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLocaleFile(t *testing.T) {
+	t.Run("Valid files", func(t *testing.T) {
+		for _, data := range []string{
+			`[]`,
+			`[{"id": "a", "translation": "A"}, {"id": "b", "translation": {"one": "B", "other": "Bs"}}]`,
+			`[{"id": "a", "translation": ""}, {"id": "b", "translation": {}}]`,
+		} {
+			assert.NoError(t, validateLocaleFile("fr.json", []byte(data)), data)
+		}
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		err := validateLocaleFile("fr.json", []byte("[\n  {\"id\": \"a\", \"translation\": \"A\"},\n  {\"id\": \"b\",,}\n]"))
+		assert.EqualError(t, err, "error parsing fr.json: line 3: invalid character ',' looking for beginning of object key string")
+		err = validateLocaleFile("fr.json", []byte(`{"id": "a"}`))
+		assert.EqualError(t, err, "error parsing fr.json: not a list of translations")
+		err = validateLocaleFile("fr.json", []byte(""))
+		assert.EqualError(t, err, "error parsing fr.json: EOF")
+		err = validateLocaleFile("fr.json", []byte("[]\n[]"))
+		assert.EqualError(t, err, "error parsing fr.json: line 2: unexpected data after the list of translations")
+	})
+
+	t.Run("Invalid entries are all reported", func(t *testing.T) {
+		err := validateLocaleFile("fr.json", []byte(`[
+  {"id": "a", "translation": "A"},
+  {"translation": "Missing"},
+  {"id": "a", "translation": "Again"},
+  {"id": "b", "translation": 1},
+  {"id": "c", "translation": {"one": "C", "other": ["Cs"]}},
+  {"id": "d"},
+  "e"
+]`))
+		assert.EqualError(t, err, `error parsing fr.json:
+  line 3: entry without id
+  line 4: duplicate id a, first defined on line 2
+  line 5: translation of b is neither a string nor an object of plural forms
+  line 6: translation of c is neither a string nor an object of plural forms
+  line 7: translation of d is neither a string nor an object of plural forms
+  line 8: entry is not an object`)
+	})
+}
+
+func TestGetBaseFileSrcStrings(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"i18n/en.json": `[{"id": "a", "translation": "A"}, {"id": "a", "translation": "B"}]`,
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := getBaseFileSrcStrings(dir)
+	assert.EqualError(t, err, "error parsing "+filepath.Join(dir, "i18n", "en.json")+": line 1: duplicate id a, first defined on line 1")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "i18n", "en.json"), []byte(`[{"id": "a", "translation": "A"}]`), 0600))
+	translations, err := getBaseFileSrcStrings(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []Translation{{Id: "a", Translation: "A"}}, translations)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var CheckParamsCmd = &cobra.Command{
+	Use:     "check-params",
+	Short:   "Check template parameters passed to translations",
+	Long:    "Check that the template parameters passed at every translation call site match the placeholders of the i18n/en.json string",
+	Example: "  i18n check-params",
+	RunE:    checkParamsCmdF,
+}
+
+func init() {
+	addExtractFlags(CheckParamsCmd)
+	addFormatFlag(CheckParamsCmd)
+
+	I18nCmd.AddCommand(CheckParamsCmd)
+}
+
+// paramsMismatch is a call site whose template parameters differ from the
+// placeholders of the en.json string.
+type paramsMismatch struct {
+	keyLocation
+	ID      string   `json:"id"`
+	Missing []string `json:"missing,omitempty"`
+	Unused  []string `json:"unused,omitempty"`
+}
+
+func (m paramsMismatch) String() string {
+	return fmt.Sprintf("%s: %s: %s", m.keyLocation, m.ID, m.message())
+}
+
+func (m paramsMismatch) message() string {
+	var problems []string
+	if len(m.Missing) > 0 {
+		problems = append(problems, "not supplied "+formatPlaceholders(m.Missing))
+	}
+	if len(m.Unused) > 0 {
+		problems = append(problems, "unused parameters "+strings.Join(m.Unused, ", "))
+	}
+	return strings.Join(problems, "; ")
+}
+
+// checkParams compares the parameters of every call passing known ones with
+// the placeholders of the source string. Keys absent from the source are left
+// to i18n check.
+func checkParams(calls []translationCall, source map[string]Translation) []paramsMismatch {
+	var mismatches []paramsMismatch
+	for _, call := range calls {
+		if !call.KnownParams {
+			continue
+		}
+		src, ok := source[call.ID]
+		if !ok {
+			continue
+		}
+		missing, unused := comparePlaceholders(templatePlaceholders(src.Translation), call.Params)
+		if len(missing) == 0 && len(unused) == 0 {
+			continue
+		}
+		mismatches = append(mismatches, paramsMismatch{keyLocation: call.keyLocation, ID: call.ID, Missing: missing, Unused: unused})
+	}
+	return mismatches
+}
+
+func checkParamsCmdF(command *cobra.Command, args []string) error {
+	r, err := newReporter(command)
+	if err != nil {
+		return err
+	}
+	opts, err := getExtractOptions(command)
+	if err != nil {
+		return err
+	}
+	sourceStrings, err := getBaseFileSrcStrings(opts.TranslationDir)
+	if err != nil {
+		return err
+	}
+	extracted, err := extractSrcStrings(opts)
+	if err != nil {
+		command.SilenceUsage = true
+		return err
+	}
+
+	mismatches := checkParams(extracted.Calls, translationsByID(sourceStrings))
+	for _, m := range mismatches {
+		r.report(m.String(), finding{
+			RuleID:   "params-mismatch",
+			Severity: severityError,
+			Key:      m.ID,
+			Message:  m.message(),
+		}.at(m.keyLocation))
+	}
+	if err = r.flush(); err != nil {
+		return err
+	}
+	if len(mismatches) > 0 {
+		command.SilenceUsage = true
+		return errors.New("translation parameters mismatch found")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckParams(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"app/a.go": `package app
+
+const nameParam = "Name"
+
+func f(params map[string]interface{}) {
+	c.T("app.hello", map[string]interface{}{nameParam: n})
+	c.T("app.hello")
+	c.T("app.hello", map[string]interface{}{"Name": n, "Extra": e})
+	c.T("app.hello", params)
+	model.NewAppError("f", "app.error", nil, "", 0)
+	c.T("app.missing_from_source")
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	opts := &extractOptions{MattermostDir: dir, TranslationDir: dir, SkipDynamic: true, Config: defaultI18nConfig()}
+	result, err := extractSrcStrings(opts)
+	require.NoError(t, err)
+
+	source := translationsByID([]Translation{
+		{Id: "app.hello", Translation: "Hello {{.Name}}"},
+		{Id: "app.error", Translation: "Error for {{.UserId}}"},
+	})
+	filename := filepath.Join(dir, "app/a.go")
+	mismatches := checkParams(result.Calls, source)
+	assert.Equal(t, []paramsMismatch{
+		{keyLocation: keyLocation{File: filename, Line: 7}, ID: "app.hello", Missing: []string{"Name"}},
+		{keyLocation: keyLocation{File: filename, Line: 8}, ID: "app.hello", Unused: []string{"Extra"}},
+		{keyLocation: keyLocation{File: filename, Line: 10}, ID: "app.error", Missing: []string{"UserId"}},
+	}, mismatches)
+	assert.Equal(t, filename+":8: app.hello: unused parameters Extra", mismatches[1].String())
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var CheckPlaceholdersCmd = &cobra.Command{
+	Use:     "check-placeholders",
+	Short:   "Check translation placeholders",
+	Long:    "Check that the template placeholders of every locale file translation match the ones of the i18n/en.json source string",
+	Example: "  i18n check-placeholders",
+	RunE:    checkPlaceholdersCmdF,
+}
+
+var (
+	templateActionRegexp = regexp.MustCompile(`(?s)\{\{(.*?)\}\}`)
+	// placeholderRegexp matches the first field of a chain like .User.Name,
+	// the one looked up in the template data.
+	placeholderRegexp = regexp.MustCompile(`(?:^|[^\w.)\]])\.([A-Za-z_]\w*)`)
+)
+
+func init() {
+	addLocaleDirFlags(CheckPlaceholdersCmd)
+	addFormatFlag(CheckPlaceholdersCmd)
+
+	I18nCmd.AddCommand(CheckPlaceholdersCmd)
+}
+
+// placeholderMismatch is a locale translation whose placeholders differ from
+// the en.json source string.
+type placeholderMismatch struct {
+	File       string   `json:"file"`
+	ID         string   `json:"id"`
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+}
+
+func (m placeholderMismatch) String() string {
+	return fmt.Sprintf("%s: %s: %s", m.File, m.ID, m.message())
+}
+
+func (m placeholderMismatch) message() string {
+	var problems []string
+	if len(m.Missing) > 0 {
+		problems = append(problems, "missing "+formatPlaceholders(m.Missing))
+	}
+	if len(m.Unexpected) > 0 {
+		problems = append(problems, "unexpected "+formatPlaceholders(m.Unexpected))
+	}
+	return strings.Join(problems, "; ")
+}
+
+func formatPlaceholders(names []string) string {
+	var formatted []string
+	for _, name := range names {
+		formatted = append(formatted, "{{."+name+"}}")
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// templatePlaceholders returns the fields referenced by the template actions
+// of every form of a translation value.
+func templatePlaceholders(value interface{}) map[string]bool {
+	placeholders := map[string]bool{}
+	for _, text := range translationForms(value) {
+		for _, action := range templateActionRegexp.FindAllStringSubmatch(text, -1) {
+			for _, field := range placeholderRegexp.FindAllStringSubmatch(action[1], -1) {
+				placeholders[field[1]] = true
+			}
+		}
+	}
+	return placeholders
+}
+
+// comparePlaceholders returns the placeholders of the source missing from the
+// target and the placeholders of the target absent from the source.
+func comparePlaceholders(source, target map[string]bool) (missing, unexpected []string) {
+	for name := range source {
+		if !target[name] {
+			missing = append(missing, name)
+		}
+	}
+	for name := range target {
+		if !source[name] {
+			unexpected = append(unexpected, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	return missing, unexpected
+}
+
+// checkPlaceholders compares every translation of a locale file with its
+// source string. Untranslated entries and entries absent from the source are
+// skipped.
+func checkPlaceholders(file string, source map[string]Translation, translations []Translation) []placeholderMismatch {
+	var mismatches []placeholderMismatch
+	for _, t := range translations {
+		src, ok := source[t.Id]
+		if !ok || isEmptyTranslation(t.Translation) {
+			continue
+		}
+		missing, unexpected := comparePlaceholders(templatePlaceholders(src.Translation), templatePlaceholders(t.Translation))
+		if len(missing) == 0 && len(unexpected) == 0 {
+			continue
+		}
+		mismatches = append(mismatches, placeholderMismatch{File: file, ID: t.Id, Missing: missing, Unexpected: unexpected})
+	}
+	return mismatches
+}
+
+func checkPlaceholdersCmdF(command *cobra.Command, args []string) error {
+	r, err := newReporter(command)
+	if err != nil {
+		return err
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	sourceStrings, err := loadTranslations(path.Join(translationDir, baseLocaleFile))
+	if err != nil {
+		return err
+	}
+	source := translationsByID(sourceStrings)
+
+	files, err := getLocaleFiles(translationDir)
+	if err != nil {
+		return err
+	}
+	var mismatches []placeholderMismatch
+	for _, file := range files {
+		translations, err2 := loadTranslations(path.Join(translationDir, file))
+		if err2 != nil {
+			return err2
+		}
+		mismatches = append(mismatches, checkPlaceholders(file, source, translations)...)
+	}
+
+	for _, m := range mismatches {
+		filename := path.Join(translationDir, m.File)
+		r.report(m.String(), finding{
+			RuleID:   "placeholder-mismatch",
+			Severity: severityError,
+			Key:      m.ID,
+			File:     filename,
+			Line:     r.keyLine(filename, m.ID),
+			Message:  m.message(),
+		})
+	}
+	if err = r.flush(); err != nil {
+		return err
+	}
+	if len(mismatches) > 0 {
+		command.SilenceUsage = true
+		return errors.New("translation placeholders mismatch found")
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplatePlaceholders(t *testing.T) {
+	assert.Equal(t, map[string]bool{"Username": true, "Count": true}, templatePlaceholders("{{.Username}} has {{ .Count | printf \"%d\" }} items"))
+	assert.Equal(t, map[string]bool{"Count": true, "Name": true}, templatePlaceholders(map[string]interface{}{
+		"one":   "One item for {{.Name}}",
+		"other": "{{.Count}} items for {{.Name}}",
+	}))
+	assert.Equal(t, map[string]bool{"User": true, "Team": true}, templatePlaceholders("{{.User.Name}} joined {{printf \"%s\" .Team.DisplayName}}"))
+	assert.Empty(t, templatePlaceholders("No placeholders. Really."))
+}
+
+func TestCheckPlaceholders(t *testing.T) {
+	source := translationsByID([]Translation{
+		{Id: "greeting", Translation: "Hello {{.Username}}"},
+		{Id: "items", Translation: map[string]interface{}{"one": "One item", "other": "{{.Count}} items"}},
+		{Id: "plain", Translation: "Plain"},
+	})
+	translations := []Translation{
+		{Id: "greeting", Translation: "Bonjour {{.UserName}}"},
+		{Id: "items", Translation: map[string]interface{}{"one": "Un élément", "other": "{{.Count}} éléments"}},
+		{Id: "plain", Translation: ""},
+		{Id: "orphan", Translation: "{{.Whatever}}"},
+	}
+
+	mismatches := checkPlaceholders("fr.json", source, translations)
+	assert.Equal(t, []placeholderMismatch{
+		{File: "fr.json", ID: "greeting", Missing: []string{"Username"}, Unexpected: []string{"UserName"}},
+	}, mismatches)
+	assert.Equal(t, "fr.json: greeting: missing {{.Username}}; unexpected {{.UserName}}", mismatches[0].String())
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const pluralOther = "other"
+
+var CheckPluralsCmd = &cobra.Command{
+	Use:     "check-plurals",
+	Short:   "Check translation plural forms",
+	Long:    "Check that the plural forms of every locale file translation match the CLDR plural rules of its language and the shape of the i18n/en.json source string",
+	Example: "  i18n check-plurals",
+	RunE:    checkPluralsCmdF,
+}
+
+func init() {
+	addLocaleDirFlags(CheckPluralsCmd)
+	addFormatFlag(CheckPluralsCmd)
+
+	I18nCmd.AddCommand(CheckPluralsCmd)
+}
+
+// cldrPluralCategories lists the CLDR cardinal plural categories of each
+// language, grouped the same way as the plural rules of the go-i18n bundle
+// used by the server.
+var cldrPluralCategories = func() map[string][]string {
+	groups := []struct {
+		languages  string
+		categories []string
+	}{
+		{"bm bo dz id ig ii in ja jbo jv jw kde kea km ko lkt lo ms my nqo root sah ses sg th to vi wo yo zh", []string{"other"}},
+		{"af ak am as asa ast az bem bez bg bh bn brx ca ce cgg chr ckb da de dv ee el en eo es et eu fa ff fi fil fo fr fur fy gl gsw gu guw ha haw hi hu hy is it jgo ji jmc ka kab kaj kcg kk kkj kl kn ks ksb ku ky lb lg ln mas mg mgo mk ml mn mr nah nb nd ne nl nn nnh no nr nso ny nyn om or os pa pap ps pt rm rof rwk saq sdh seh si sn so sq ss ssy st sv sw syr ta te teo ti tig tk tl tn tr ts tzm ug ur uz ve vo vun wa wae xh xog yi zu", []string{"one", "other"}},
+		{"ksh lag lv prg", []string{"zero", "one", "other"}},
+		{"iu kw naq se sma smi smj smn sms", []string{"one", "two", "other"}},
+		{"bs hr mo ro sh shi sr", []string{"one", "few", "other"}},
+		{"dsb gd hsb sl", []string{"one", "two", "few", "other"}},
+		{"he iw", []string{"one", "two", "many", "other"}},
+		{"be cs lt mt pl ru sk uk", []string{"one", "few", "many", "other"}},
+		{"br ga gv", []string{"one", "two", "few", "many", "other"}},
+		{"ar cy", []string{"zero", "one", "two", "few", "many", "other"}},
+	}
+	categories := map[string][]string{}
+	for _, group := range groups {
+		for _, language := range strings.Fields(group.languages) {
+			categories[language] = group.categories
+		}
+	}
+	return categories
+}()
+
+// pluralCategories returns the plural categories of the language of a locale
+// file like pt-BR.json, or nil when the language is unknown.
+func pluralCategories(file string) []string {
+	locale := strings.ToLower(strings.TrimSuffix(file, path.Ext(file)))
+	locale = strings.Replace(locale, "_", "-", -1)
+	if categories, ok := cldrPluralCategories[locale]; ok {
+		return categories
+	}
+	return cldrPluralCategories[strings.Split(locale, "-")[0]]
+}
+
+// pluralError is a translation whose plural forms are invalid.
+type pluralError struct {
+	File    string `json:"file"`
+	ID      string `json:"id"`
+	Problem string `json:"problem"`
+}
+
+func (e pluralError) String() string {
+	return fmt.Sprintf("%s: %s: %s", e.File, e.ID, e.Problem)
+}
+
+// checkPlurals validates the plural objects of a locale file against the
+// plural rules of its language and the shape of the source strings. source
+// is nil when checking the source file itself.
+func checkPlurals(file string, source map[string]Translation, translations []Translation) []pluralError {
+	categories := pluralCategories(file)
+	var errs []pluralError
+	for _, t := range translations {
+		forms, isPlural := t.Translation.(map[string]interface{})
+		if source != nil {
+			src, ok := source[t.Id]
+			if !ok {
+				continue
+			}
+			_, srcIsPlural := src.Translation.(map[string]interface{})
+			if srcIsPlural && !isPlural && !isEmptyTranslation(t.Translation) {
+				errs = append(errs, pluralError{File: file, ID: t.Id, Problem: "source has plural forms but translation is a plain string"})
+				continue
+			}
+			if !srcIsPlural && isPlural {
+				errs = append(errs, pluralError{File: file, ID: t.Id, Problem: "translation has plural forms but source is a plain string"})
+				continue
+			}
+		}
+		if !isPlural {
+			continue
+		}
+
+		if _, ok := forms[pluralOther]; !ok {
+			errs = append(errs, pluralError{File: file, ID: t.Id, Problem: "missing plural form other"})
+		}
+		if categories == nil {
+			continue
+		}
+		var missing, unexpected []string
+		valid := map[string]bool{}
+		for _, category := range categories {
+			valid[category] = true
+			if _, ok := forms[category]; !ok && category != pluralOther {
+				missing = append(missing, category)
+			}
+		}
+		for form := range forms {
+			if !valid[form] {
+				unexpected = append(unexpected, form)
+			}
+		}
+		sort.Strings(unexpected)
+		if len(missing) > 0 {
+			errs = append(errs, pluralError{File: file, ID: t.Id, Problem: "missing plural forms " + strings.Join(missing, ", ")})
+		}
+		if len(unexpected) > 0 {
+			errs = append(errs, pluralError{File: file, ID: t.Id, Problem: "unexpected plural forms " + strings.Join(unexpected, ", ")})
+		}
+	}
+	return errs
+}
+
+func checkPluralsCmdF(command *cobra.Command, args []string) error {
+	r, err := newReporter(command)
+	if err != nil {
+		return err
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	sourceStrings, err := loadTranslations(path.Join(translationDir, baseLocaleFile))
+	if err != nil {
+		return err
+	}
+	source := translationsByID(sourceStrings)
+
+	files, err := getLocaleFiles(translationDir)
+	if err != nil {
+		return err
+	}
+	errs := checkPlurals(baseLocaleFile, nil, sourceStrings)
+	for _, file := range files {
+		translations, err2 := loadTranslations(path.Join(translationDir, file))
+		if err2 != nil {
+			return err2
+		}
+		errs = append(errs, checkPlurals(file, source, translations)...)
+	}
+
+	for _, e := range errs {
+		filename := path.Join(translationDir, e.File)
+		r.report(e.String(), finding{
+			RuleID:   "invalid-plural",
+			Severity: severityError,
+			Key:      e.ID,
+			File:     filename,
+			Line:     r.keyLine(filename, e.ID),
+			Message:  e.Problem,
+		})
+	}
+	if err = r.flush(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		command.SilenceUsage = true
+		return errors.New("invalid plural forms found")
+	}
+	return nil
+}
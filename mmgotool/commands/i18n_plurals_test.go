@@ -0,0 +1,63 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluralCategories(t *testing.T) {
+	assert.Equal(t, []string{"one", "few", "many", "other"}, pluralCategories("pl.json"))
+	assert.Equal(t, []string{"one", "other"}, pluralCategories("pt-BR.json"))
+	assert.Equal(t, []string{"other"}, pluralCategories("zh_TW.json"))
+	assert.Nil(t, pluralCategories("xx.json"))
+}
+
+func TestCheckPlurals(t *testing.T) {
+	source := translationsByID([]Translation{
+		{Id: "items", Translation: map[string]interface{}{"one": "One item", "other": "{{.Count}} items"}},
+		{Id: "plain", Translation: "Plain"},
+	})
+
+	t.Run("Source file", func(t *testing.T) {
+		errs := checkPlurals("en.json", nil, []Translation{
+			{Id: "items", Translation: map[string]interface{}{"one": "One item", "few": "Few items"}},
+		})
+		assert.Equal(t, []pluralError{
+			{File: "en.json", ID: "items", Problem: "missing plural form other"},
+			{File: "en.json", ID: "items", Problem: "unexpected plural forms few"},
+		}, errs)
+	})
+
+	t.Run("Polish needs few and many", func(t *testing.T) {
+		errs := checkPlurals("pl.json", source, []Translation{
+			{Id: "items", Translation: map[string]interface{}{"one": "Jeden", "other": "{{.Count}}"}},
+			{Id: "plain", Translation: "Zwykły"},
+			{Id: "orphan", Translation: map[string]interface{}{"one": "Jeden"}},
+		})
+		assert.Equal(t, []pluralError{
+			{File: "pl.json", ID: "items", Problem: "missing plural forms few, many"},
+		}, errs)
+	})
+
+	t.Run("Shape mismatch with the source", func(t *testing.T) {
+		errs := checkPlurals("fr.json", source, []Translation{
+			{Id: "items", Translation: "Des éléments"},
+			{Id: "plain", Translation: map[string]interface{}{"one": "Un", "other": "Plusieurs"}},
+		})
+		assert.Equal(t, []pluralError{
+			{File: "fr.json", ID: "items", Problem: "source has plural forms but translation is a plain string"},
+			{File: "fr.json", ID: "plain", Problem: "translation has plural forms but source is a plain string"},
+		}, errs)
+	})
+
+	t.Run("Untranslated entries are skipped", func(t *testing.T) {
+		assert.Empty(t, checkPlurals("ja.json", source, []Translation{
+			{Id: "items", Translation: ""},
+			{Id: "plain", Translation: ""},
+		}))
+	})
+}
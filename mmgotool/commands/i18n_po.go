@@ -0,0 +1,435 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// poPluralForms is a Gettext plural formula and the CLDR category of the
+// plural form selected by each of its msgstr indexes.
+type poPluralForms struct {
+	Header     string
+	Categories []string
+}
+
+// gettextPluralForms maps languages to the Gettext plural formula used for
+// them by translation tools, grouped the same way as cldrPluralCategories.
+var gettextPluralForms = func() map[string]poPluralForms {
+	groups := []struct {
+		languages string
+		poPluralForms
+	}{
+		{"bm bo dz id ig ii in ja jbo jv jw kde kea km ko lkt lo ms my nqo root sah ses sg th to vi wo yo zh",
+			poPluralForms{"nplurals=1; plural=0;", []string{"other"}}},
+		{"af asa ast az bem bez bg bh brx ca ce cgg chr ckb da de dv ee el en eo es et eu fi fil fo fur fy gl gsw ha haw hu is it jgo ji jmc ka kaj kcg kk kkj kl ks ksb ku ky lb lg mas mgo mk ml mn mr nah nb nd ne nl nn nnh no nr ny nyn om or os pap ps rm rof rwk saq sdh seh si sn so sq ss ssy st sv sw syr ta te teo tig tk tl tn tr ts tzm ug ur uz ve vo vun wae xh xog yi",
+			poPluralForms{"nplurals=2; plural=(n != 1);", []string{"one", "other"}}},
+		{"ak am as bn fa ff fr gu guw hi hy kab kn ln mg nso pa pt ti wa zu",
+			poPluralForms{"nplurals=2; plural=(n > 1);", []string{"one", "other"}}},
+		{"lv prg", poPluralForms{"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);", []string{"one", "other", "zero"}}},
+		{"ksh lag", poPluralForms{"nplurals=3; plural=(n==0 ? 0 : n==1 ? 1 : 2);", []string{"zero", "one", "other"}}},
+		{"iu kw naq se sma smi smj smn sms", poPluralForms{"nplurals=3; plural=(n==1 ? 0 : n==2 ? 1 : 2);", []string{"one", "two", "other"}}},
+		{"bs hr sh sr", poPluralForms{"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);", []string{"one", "few", "other"}}},
+		{"mo ro", poPluralForms{"nplurals=3; plural=(n==1 ? 0 : (n==0 || (n%100>0 && n%100<20)) ? 1 : 2);", []string{"one", "few", "other"}}},
+		{"shi", poPluralForms{"nplurals=3; plural=(n==0 || n==1 ? 0 : n>=2 && n<=10 ? 1 : 2);", []string{"one", "few", "other"}}},
+		{"dsb hsb sl", poPluralForms{"nplurals=4; plural=(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3);", []string{"one", "two", "few", "other"}}},
+		{"gd", poPluralForms{"nplurals=4; plural=((n==1 || n==11) ? 0 : (n==2 || n==12) ? 1 : (n>2 && n<20) ? 2 : 3);", []string{"one", "two", "few", "other"}}},
+		{"he iw", poPluralForms{"nplurals=4; plural=(n==1 ? 0 : n==2 ? 1 : n>10 && n%10==0 ? 2 : 3);", []string{"one", "two", "many", "other"}}},
+		{"pl", poPluralForms{"nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);", []string{"one", "few", "many"}}},
+		{"be ru uk", poPluralForms{"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);", []string{"one", "few", "many"}}},
+		{"cs sk", poPluralForms{"nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);", []string{"one", "few", "other"}}},
+		{"lt", poPluralForms{"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && (n%100<10 || n%100>=20) ? 1 : 2);", []string{"one", "few", "other"}}},
+		{"mt", poPluralForms{"nplurals=4; plural=(n==1 ? 0 : n==0 || (n%100>1 && n%100<11) ? 1 : n%100>10 && n%100<20 ? 2 : 3);", []string{"one", "few", "many", "other"}}},
+		{"br ga gv", poPluralForms{"nplurals=5; plural=(n==1 ? 0 : n==2 ? 1 : n>2 && n<7 ? 2 : n>6 && n<11 ? 3 : 4);", []string{"one", "two", "few", "many", "other"}}},
+		{"ar", poPluralForms{"nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);", []string{"zero", "one", "two", "few", "many", "other"}}},
+		{"cy", poPluralForms{"nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n==3 ? 3 : n==6 ? 4 : 5);", []string{"zero", "one", "two", "few", "many", "other"}}},
+	}
+	forms := map[string]poPluralForms{}
+	for _, group := range groups {
+		for _, language := range strings.Fields(group.languages) {
+			forms[language] = group.poPluralForms
+		}
+	}
+	return forms
+}()
+
+// localePluralForms returns the Gettext plural formula of the language of a
+// locale like pt-BR.
+func localePluralForms(locale string) (poPluralForms, bool) {
+	locale = strings.Replace(strings.ToLower(locale), "_", "-", -1)
+	if forms, ok := gettextPluralForms[locale]; ok {
+		return forms, true
+	}
+	forms, ok := gettextPluralForms[strings.Split(locale, "-")[0]]
+	return forms, ok
+}
+
+// parsePluralForms returns the categories of a Plural-Forms header value. The
+// formula must be one of gettextPluralForms, spacing aside.
+func parsePluralForms(header string) (poPluralForms, error) {
+	normalize := func(s string) string {
+		return strings.TrimSuffix(strings.Join(strings.Fields(s), ""), ";")
+	}
+	for _, forms := range gettextPluralForms {
+		if normalize(forms.Header) == normalize(header) {
+			return forms, nil
+		}
+	}
+	return poPluralForms{}, fmt.Errorf("unsupported Plural-Forms %q", header)
+}
+
+// poPluralText returns the text of the msgstr of category. Gettext formulas
+// only select the forms of integers, so languages whose CLDR other category
+// is only used for fractions have no msgstr for it, and those having one have
+// none for the many category used for fractions. The missing category takes
+// the text of the other one.
+func poPluralText(target map[string]string, category string) string {
+	if text := target[category]; text != "" || category != "many" {
+		return text
+	}
+	return target[pluralOther]
+}
+
+// completePluralForms adds the categories of locale that the Plural-Forms
+// formula has no msgstr for to target, see poPluralText.
+func completePluralForms(target map[string]string, locale string) {
+	if len(target) == 0 {
+		return
+	}
+	for _, category := range pluralCategories(locale + ".json") {
+		if _, ok := target[category]; ok {
+			continue
+		}
+		if category == pluralOther {
+			target[category] = target["many"]
+		} else if category == "many" {
+			target[category] = target[pluralOther]
+		}
+	}
+}
+
+// writePO writes file as a Gettext PO file. Every entry uses the translation
+// key as msgctxt and the en.json source string as msgid. The msgstr indexes
+// of plural entries follow the Plural-Forms formula of the locale.
+func writePO(w io.Writer, file *exchangeFile) error {
+	forms, knownForms := localePluralForms(file.Locale)
+	headers := []string{
+		"Language: " + file.Locale,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	if knownForms {
+		headers = append(headers, "Plural-Forms: "+forms.Header)
+	}
+	for _, unit := range file.Units {
+		if unit.Plural && !knownForms {
+			return fmt.Errorf("no Gettext plural formula known for locale %s", file.Locale)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s translations of %s\n", file.Locale, baseLocaleFile)
+	writePOField(bw, "msgid", "")
+	writePOField(bw, "msgstr", strings.Join(append(headers, ""), "\n"))
+	for _, unit := range file.Units {
+		bw.WriteString("\n")
+		writePOField(bw, "msgctxt", unit.ID)
+		if !unit.Plural {
+			writePOField(bw, "msgid", unit.Source[""])
+			writePOField(bw, "msgstr", unit.Target[""])
+			continue
+		}
+		writePOField(bw, "msgid", unit.pluralSource("one"))
+		writePOField(bw, "msgid_plural", unit.pluralSource(pluralOther))
+		for i, category := range forms.Categories {
+			writePOField(bw, fmt.Sprintf("msgstr[%d]", i), poPluralText(unit.Target, category))
+		}
+	}
+	return bw.Flush()
+}
+
+// writePOField writes a keyword and its quoted value, splitting multi-line
+// values after each newline the way Gettext tools do.
+func writePOField(w *bufio.Writer, keyword, value string) {
+	lines := strings.SplitAfter(value, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		fmt.Fprintf(w, "%s %s\n", keyword, poQuote(value))
+		return
+	}
+	fmt.Fprintf(w, "%s \"\"\n", keyword)
+	for _, line := range lines {
+		fmt.Fprintln(w, poQuote(line))
+	}
+}
+
+var poEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"\a", `\a`,
+	"\b", `\b`,
+	"\f", `\f`,
+	"\v", `\v`,
+)
+
+func poQuote(s string) string {
+	return `"` + poEscaper.Replace(s) + `"`
+}
+
+func poUnquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	s = s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' {
+			return "", fmt.Errorf("unescaped quote in %q", s)
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("trailing backslash in %q", s)
+		}
+		switch c = s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '"', '\'', '?':
+			b.WriteByte(c)
+		case 'x':
+			j := i + 1
+			for j < len(s) && j < i+3 && isHexDigit(s[j]) {
+				j++
+			}
+			value, err := strconv.ParseUint(s[i+1:j], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence in %q", s)
+			}
+			b.WriteByte(byte(value))
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			value, err := strconv.ParseUint(s[i:j], 8, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence in %q", s)
+			}
+			b.WriteByte(byte(value))
+			i = j - 1
+		default:
+			return "", fmt.Errorf("invalid escape sequence \\%c in %q", c, s)
+		}
+	}
+	return b.String(), nil
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// poEntry is a message of a PO file, its fields are keyed by keyword like
+// msgid or msgstr[0].
+type poEntry struct {
+	Line     int
+	Fields   map[string]string
+	Fuzzy    bool
+	Obsolete bool
+}
+
+func (e *poEntry) hasMsgstr() bool {
+	for keyword := range e.Fields {
+		if strings.HasPrefix(keyword, "msgstr") {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePOEntries(data []byte) ([]*poEntry, error) {
+	var entries []*poEntry
+	entry := &poEntry{Fields: map[string]string{}}
+	lastKeyword := ""
+	finish := func() {
+		if len(entry.Fields) > 0 {
+			entries = append(entries, entry)
+		}
+		entry = &poEntry{Fields: map[string]string{}}
+		lastKeyword = ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		switch {
+		case line == "":
+			finish()
+		case strings.HasPrefix(line, "#~"):
+			entry.Obsolete = true
+		case strings.HasPrefix(line, "#"):
+			if entry.hasMsgstr() {
+				finish()
+			}
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				entry.Fuzzy = true
+			}
+		case strings.HasPrefix(line, `"`):
+			if lastKeyword == "" {
+				return nil, fmt.Errorf("line %d: string outside of a message", lineNumber)
+			}
+			value, err := poUnquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			entry.Fields[lastKeyword] += value
+		default:
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: invalid line %q", lineNumber, line)
+			}
+			keyword := fields[0]
+			if (keyword == "msgctxt" || keyword == "msgid") && entry.hasMsgstr() {
+				finish()
+			}
+			if keyword != "msgctxt" && keyword != "msgid" && keyword != "msgid_plural" && !strings.HasPrefix(keyword, "msgstr") {
+				return nil, fmt.Errorf("line %d: unknown keyword %s", lineNumber, keyword)
+			}
+			value, err := poUnquote(strings.TrimSpace(fields[1]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			if entry.Line == 0 {
+				entry.Line = lineNumber
+			}
+			entry.Fields[keyword] = value
+			lastKeyword = keyword
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finish()
+	return entries, nil
+}
+
+// readPO reads a PO file written by writePO or a translation tool. The
+// locale defaults to the Language header, then to locale. The msgstr indexes
+// of plural entries are mapped to categories with the Plural-Forms header.
+// Entries that are fuzzy or have an empty msgstr are read as cleared.
+func readPO(data []byte, locale string) (*exchangeFile, error) {
+	entries, err := parsePOEntries(data)
+	if err != nil {
+		return nil, err
+	}
+	file := &exchangeFile{Locale: locale}
+	var forms *poPluralForms
+	for _, entry := range entries {
+		_, hasContext := entry.Fields["msgctxt"]
+		if hasContext || entry.Fields["msgid"] != "" || entry.Obsolete {
+			continue
+		}
+		for _, header := range strings.Split(entry.Fields["msgstr"], "\n") {
+			parts := strings.SplitN(header, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			switch name {
+			case "Language":
+				if value != "" {
+					file.Locale = value
+				}
+			case "Plural-Forms":
+				parsed, err := parsePluralForms(value)
+				if err != nil {
+					return nil, err
+				}
+				forms = &parsed
+			}
+		}
+	}
+	categories := map[string]bool{}
+	for _, category := range pluralCategories(file.Locale + ".json") {
+		categories[category] = true
+	}
+	if forms != nil {
+		for _, category := range forms.Categories {
+			categories[category] = true
+		}
+	}
+	file.Categories = sortPluralCategories(categories)
+
+	for _, entry := range entries {
+		id, hasContext := entry.Fields["msgctxt"]
+		if entry.Obsolete || (!hasContext && entry.Fields["msgid"] == "") {
+			continue
+		}
+		if !hasContext {
+			return nil, fmt.Errorf("line %d: message without msgctxt holding its translation key", entry.Line)
+		}
+		unit := exchangeUnit{ID: id, Target: map[string]string{}}
+		_, unit.Plural = entry.Fields["msgid_plural"]
+		if !unit.Plural {
+			unit.Source = map[string]string{"": entry.Fields["msgid"]}
+			if !entry.Fuzzy {
+				unit.Target[""] = entry.Fields["msgstr"]
+			}
+			unit.Cleared = isEmptyTranslation(unit.value())
+			file.Units = append(file.Units, unit)
+			continue
+		}
+		unit.Source = map[string]string{"one": entry.Fields["msgid"], pluralOther: entry.Fields["msgid_plural"]}
+		if forms == nil {
+			return nil, fmt.Errorf("line %d: plural message without a Plural-Forms header", entry.Line)
+		}
+		for keyword, text := range entry.Fields {
+			if !strings.HasPrefix(keyword, "msgstr[") || !strings.HasSuffix(keyword, "]") {
+				continue
+			}
+			index, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil || index < 0 || index >= len(forms.Categories) {
+				return nil, fmt.Errorf("line %d: %s does not match the plural categories %s", entry.Line, keyword, strings.Join(forms.Categories, ", "))
+			}
+			if !entry.Fuzzy {
+				unit.Target[forms.Categories[index]] = text
+			}
+		}
+		completePluralForms(unit.Target, file.Locale)
+		unit.Cleared = isEmptyTranslation(unit.value())
+		file.Units = append(file.Units, unit)
+	}
+	return file, nil
+}
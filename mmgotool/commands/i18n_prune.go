@@ -0,0 +1,136 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/spf13/cobra"
+)
+
+var PruneCmd = &cobra.Command{
+	Use:     "prune",
+	Short:   "Prune orphaned translations",
+	Long:    "Remove the keys absent from the i18n/en.json base file from every other translation file",
+	Example: "  i18n prune --dry-run",
+	RunE:    pruneCmdF,
+}
+
+func init() {
+	PruneCmd.Flags().Bool("dry-run", false, "Run without applying changes")
+	PruneCmd.Flags().Bool("check", false, "Throw exit code on orphaned translations")
+	addLocaleDirFlags(PruneCmd)
+	addFormatFlag(PruneCmd)
+
+	I18nCmd.AddCommand(PruneCmd)
+}
+
+// removeOrphanedTranslations returns the items whose id is in sourceIDs along
+// with the ids of the removed ones.
+func removeOrphanedTranslations(oldList []Item, sourceIDs map[string]bool) ([]Item, []string) {
+	var removed []string
+	var newList []Item
+	for _, t := range oldList {
+		if sourceIDs[t.ID] {
+			newList = append(newList, t)
+		} else {
+			removed = append(removed, t.ID)
+		}
+	}
+	return newList, removed
+}
+
+func prune(translationDir string, file string, sourceIDs map[string]bool, dryRun bool, check bool, r *reporter) (string, error) {
+	filename := path.Join(translationDir, file)
+	oldList, err := loadItems(filename)
+	if err != nil {
+		return "", err
+	}
+	newList, removed := removeOrphanedTranslations(oldList, sourceIDs)
+	if len(removed) == 0 {
+		return "", nil
+	}
+	result := fmt.Sprintf("%v has %v orphaned translations\n", file, len(removed))
+	severity, message := severityWarning, "orphaned translation removed"
+	if dryRun || check {
+		message = "orphaned translation"
+	}
+	if check {
+		severity = severityError
+	}
+	for _, id := range removed {
+		r.report("", finding{
+			RuleID:   "orphaned-key",
+			Severity: severity,
+			Key:      id,
+			File:     filename,
+			Line:     r.keyLine(filename, id),
+			Message:  message,
+		})
+	}
+	if dryRun || check {
+		return result, nil
+	}
+
+	if err = writeLocaleFile(filename, newList); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func pruneCmdF(command *cobra.Command, args []string) error {
+	r, err := newReporter(command)
+	if err != nil {
+		return err
+	}
+	dryRun, err := command.Flags().GetBool("dry-run")
+	if err != nil {
+		return errors.New("invalid dry-run parameter")
+	}
+	check, err := command.Flags().GetBool("check")
+	if err != nil {
+		return errors.New("invalid check parameter")
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	sourceStrings, err := loadTranslations(path.Join(translationDir, baseLocaleFile))
+	if err != nil {
+		return err
+	}
+	sourceIDs := map[string]bool{}
+	for _, t := range sourceStrings {
+		sourceIDs[t.Id] = true
+	}
+	files, err := getLocaleFiles(translationDir)
+	if err != nil {
+		return err
+	}
+
+	results := ""
+	for _, file := range files {
+		result, err2 := prune(translationDir, file, sourceIDs, dryRun, check, r)
+		if err2 != nil {
+			return err2
+		}
+		results += result
+	}
+	if err = r.flush(); err != nil {
+		return err
+	}
+	if results == "" {
+		return nil
+	}
+	if r.isText() {
+		fmt.Print(results)
+	}
+	if check {
+		command.SilenceUsage = true
+		return errors.New("orphaned translations found")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveOrphanedTranslations(t *testing.T) {
+	items := []Item{
+		{ID: "kept", Translation: json.RawMessage(`"Kept"`)},
+		{ID: "orphan", Translation: json.RawMessage(`"Orphan"`)},
+		{ID: "plural", Translation: json.RawMessage(`{"one": "One", "other": "Many"}`)},
+	}
+	newList, removed := removeOrphanedTranslations(items, map[string]bool{"kept": true, "plural": true})
+	assert.Equal(t, []Item{items[0], items[2]}, newList)
+	assert.Equal(t, []string{"orphan"}, removed)
+}
+
+func TestPrune(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"fr.json": `[{"id": "kept", "translation": "Gardé"}, {"id": "orphan", "translation": "Orphelin"}]`,
+		"de.json": `[{"id": "kept", "translation": "Behalten"}]`,
+	})
+	defer os.RemoveAll(dir)
+	sourceIDs := map[string]bool{"kept": true}
+	r := &reporter{format: formatJSON, lines: map[string]map[string]int{}}
+
+	t.Run("Dry run leaves the file unchanged", func(t *testing.T) {
+		result, err := prune(dir, "fr.json", sourceIDs, true, false, r)
+		require.NoError(t, err)
+		assert.Equal(t, "fr.json has 1 orphaned translations\n", result)
+		data, err := ioutil.ReadFile(filepath.Join(dir, "fr.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "orphan")
+		assert.Equal(t, []finding{{
+			RuleID:   "orphaned-key",
+			Severity: severityWarning,
+			Key:      "orphan",
+			File:     filepath.Join(dir, "fr.json"),
+			Line:     1,
+			Message:  "orphaned translation",
+		}}, r.findings)
+	})
+
+	t.Run("Orphaned keys are removed", func(t *testing.T) {
+		result, err := prune(dir, "fr.json", sourceIDs, false, false, r)
+		require.NoError(t, err)
+		assert.Equal(t, "fr.json has 1 orphaned translations\n", result)
+		translations, err := loadTranslations(filepath.Join(dir, "fr.json"))
+		require.NoError(t, err)
+		assert.Equal(t, []Translation{{Id: "kept", Translation: "Gardé"}}, translations)
+	})
+
+	t.Run("Files without orphaned keys are untouched", func(t *testing.T) {
+		result, err := prune(dir, "de.json", sourceIDs, false, false, r)
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+}
@@ -0,0 +1,315 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const i18nConfigFileName = ".mmgotool-i18n.json"
+
+// translationFunc describes a function or method whose call carries a
+// translation key as one of its arguments.
+type translationFunc struct {
+	// Name is the function or method name, e.g. "T" or "NewAppError".
+	Name string `json:"name"`
+	// Package optionally restricts the match to calls through an import of
+	// this package path, e.g. "github.com/mattermost/mattermost-server/v6/model".
+	Package string `json:"package,omitempty"`
+	// Receiver optionally restricts the match to method calls on values of
+	// this type, e.g. "*Context". The type is only enforced with
+	// --type-aware; otherwise any call made through a value rather than
+	// through a package import matches, whatever the receiver type.
+	Receiver string `json:"receiver,omitempty"`
+	// KeyArg is the zero based position of the translation key argument.
+	KeyArg int `json:"key_arg"`
+	// ParamsArg is the zero based position of the template parameters
+	// argument, if the function takes one.
+	ParamsArg *int `json:"params_arg,omitempty"`
+}
+
+var defaultTranslationFuncs = []translationFunc{
+	{Name: "T", KeyArg: 0, ParamsArg: argIndex(1)},
+	{Name: "NewAppError", KeyArg: 1, ParamsArg: argIndex(2)},
+	{Name: "newAppError", KeyArg: 0},
+	{Name: "NewUserFacingError", KeyArg: 0},
+	{Name: "translateFunc", KeyArg: 0, ParamsArg: argIndex(1)},
+	{Name: "TranslateAsHTML", KeyArg: 1, ParamsArg: argIndex(2)},
+	{Name: "TranslateAsHtml", KeyArg: 1, ParamsArg: argIndex(2)},
+	{Name: "userLocale", KeyArg: 0, ParamsArg: argIndex(1)},
+	{Name: "localT", KeyArg: 0, ParamsArg: argIndex(1)},
+}
+
+func argIndex(i int) *int {
+	return &i
+}
+
+// i18nConfig is the repository owned configuration of the i18n commands. It
+// is read from the .mmgotool-i18n.json file in the root of the scanned
+// repository, every missing section falls back to the built-in defaults.
+type i18nConfig struct {
+	Functions []translationFunc `json:"functions"`
+	// DynamicKeysFile is the path, relative to the repository root, of the
+	// file listing the keys that are built at runtime. It defaults to
+	// i18n/dynamic_keys.txt, which may be missing, while a configured file
+	// must exist.
+	DynamicKeysFile string `json:"dynamic_keys_file"`
+	// Lint configures the key naming rules checked by i18n lint.
+	Lint lintConfig `json:"lint"`
+	// EnglishCopies lists the translations allowed to be identical to the
+	// English source strings.
+	EnglishCopies englishCopiesConfig `json:"english_copies"`
+}
+
+// lintConfig holds the key naming conventions. Missing fields fall back to
+// the built-in defaults.
+type lintConfig struct {
+	// KeyPattern is the regular expression every key of en.json must match.
+	// By default keys are lowercase dotted names, or a capitalized word like
+	// the month names the server translates with the English name as key.
+	KeyPattern string `json:"key_pattern"`
+	// AppErrorFunctions are the names of the functions whose keys must end
+	// with AppErrorSuffix.
+	AppErrorFunctions []string `json:"app_error_functions"`
+	AppErrorSuffix    string   `json:"app_error_suffix"`
+	// PackagePrefixes maps package names to the prefixes allowed for the keys
+	// used in them. Keys used in other packages are not checked, so the
+	// package-prefix rule is off when empty.
+	PackagePrefixes map[string][]string `json:"package_prefixes"`
+	// DisabledRules lists the ids of the rules left unchecked.
+	DisabledRules []string `json:"disabled_rules"`
+}
+
+// englishCopiesConfig is the allowlist of i18n check-copies.
+type englishCopiesConfig struct {
+	// AllowedKeys are the keys whose translations may be copied from English.
+	AllowedKeys []string `json:"allowed_keys"`
+	// AllowedStrings are the source strings every locale may keep as they
+	// are, like product names.
+	AllowedStrings []string `json:"allowed_strings"`
+	// IgnoredLocales are the patterns, like "en-*", of the locales left
+	// unchecked. English variants are ignored by default.
+	IgnoredLocales []string `json:"ignored_locales"`
+}
+
+func defaultI18nConfig() *i18nConfig {
+	return &i18nConfig{
+		Functions: defaultTranslationFuncs,
+		Lint: lintConfig{
+			KeyPattern:        `^([a-z0-9_]+(\.[a-z0-9_]+)+|[A-Z][a-z]+)$`,
+			AppErrorFunctions: []string{"NewAppError"},
+			AppErrorSuffix:    ".app_error",
+		},
+		EnglishCopies: englishCopiesConfig{
+			IgnoredLocales: []string{"en-*"},
+		},
+	}
+}
+
+// loadI18nConfig reads the configuration from configPath or, when it is
+// empty, from the default location inside rootDir. A missing default file is
+// not an error.
+func loadI18nConfig(rootDir, configPath string) (*i18nConfig, error) {
+	explicit := configPath != ""
+	if !explicit {
+		configPath = path.Join(rootDir, i18nConfigFileName)
+	}
+
+	config := defaultI18nConfig()
+	data, err := ioutil.ReadFile(configPath)
+	if os.IsNotExist(err) && !explicit {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fileConfig i18nConfig
+	if err = json.Unmarshal(data, &fileConfig); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", configPath, err)
+	}
+	if len(fileConfig.Functions) > 0 {
+		config.Functions = fileConfig.Functions
+	}
+	if fileConfig.DynamicKeysFile != "" {
+		config.DynamicKeysFile = fileConfig.DynamicKeysFile
+	}
+	if fileConfig.Lint.KeyPattern != "" {
+		config.Lint.KeyPattern = fileConfig.Lint.KeyPattern
+	}
+	if fileConfig.Lint.AppErrorFunctions != nil {
+		config.Lint.AppErrorFunctions = fileConfig.Lint.AppErrorFunctions
+	}
+	if fileConfig.Lint.AppErrorSuffix != "" {
+		config.Lint.AppErrorSuffix = fileConfig.Lint.AppErrorSuffix
+	}
+	config.Lint.PackagePrefixes = fileConfig.Lint.PackagePrefixes
+	config.Lint.DisabledRules = fileConfig.Lint.DisabledRules
+	config.EnglishCopies.AllowedKeys = fileConfig.EnglishCopies.AllowedKeys
+	config.EnglishCopies.AllowedStrings = fileConfig.EnglishCopies.AllowedStrings
+	if fileConfig.EnglishCopies.IgnoredLocales != nil {
+		config.EnglishCopies.IgnoredLocales = fileConfig.EnglishCopies.IgnoredLocales
+	}
+	for _, f := range config.Functions {
+		if f.Name == "" {
+			return nil, fmt.Errorf("error parsing %s: translation function without name", configPath)
+		}
+		if f.KeyArg < 0 {
+			return nil, fmt.Errorf("error parsing %s: invalid key_arg %d for %s", configPath, f.KeyArg, f.Name)
+		}
+		if f.ParamsArg != nil && (*f.ParamsArg < 0 || *f.ParamsArg == f.KeyArg) {
+			return nil, fmt.Errorf("error parsing %s: invalid params_arg %d for %s", configPath, *f.ParamsArg, f.Name)
+		}
+	}
+	if _, err = regexp.Compile(config.Lint.KeyPattern); err != nil {
+		return nil, fmt.Errorf("error parsing %s: invalid lint key_pattern: %v", configPath, err)
+	}
+	for _, pattern := range config.EnglishCopies.IgnoredLocales {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("error parsing %s: invalid english_copies ignored_locales pattern %s", configPath, pattern)
+		}
+	}
+	for _, rule := range config.Lint.DisabledRules {
+		if _, ok := lintRules[rule]; !ok {
+			return nil, fmt.Errorf("error parsing %s: unknown lint rule %s", configPath, rule)
+		}
+	}
+	return config, nil
+}
+
+// funcRegistry indexes the translation functions by name for fast lookup
+// while walking the AST.
+type funcRegistry struct {
+	byName map[string][]translationFunc
+}
+
+func newFuncRegistry(funcs []translationFunc) *funcRegistry {
+	r := &funcRegistry{byName: map[string][]translationFunc{}}
+	for _, f := range funcs {
+		r.byName[f.Name] = append(r.byName[f.Name], f)
+	}
+	return r
+}
+
+// match returns the registered function called by call, or nil when the
+// call does not match any of them. imports maps the local import names of the
+// file to their package paths.
+func (r *funcRegistry) match(call *ast.CallExpr, imports map[string]string) *translationFunc {
+	var name, qualifier string
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		name = fun.Sel.Name
+		if ident, ok := fun.X.(*ast.Ident); ok {
+			qualifier = ident.Name
+		}
+	case *ast.Ident:
+		name = fun.Name
+	default:
+		return nil
+	}
+
+	candidates := r.byName[name]
+	for i := range candidates {
+		f := &candidates[i]
+		pkgPath, isPkg := imports[qualifier]
+		if f.Package != "" && (!isPkg || pkgPath != f.Package) {
+			continue
+		}
+		if f.Receiver != "" {
+			if _, ok := call.Fun.(*ast.SelectorExpr); !ok || isPkg {
+				continue
+			}
+		}
+		if len(call.Args) <= f.KeyArg {
+			continue
+		}
+		return f
+	}
+	return nil
+}
+
+// keyArg returns the translation key argument of a call matching f.
+func (f *translationFunc) keyArg(call *ast.CallExpr) ast.Expr {
+	return call.Args[f.KeyArg]
+}
+
+// params returns the names of the template parameters passed to a call
+// matching f. The second value is false when they cannot be determined from
+// the source code, e.g. when the parameters are held in a variable. eval
+// resolves the string value of the map keys.
+func (f *translationFunc) params(call *ast.CallExpr, eval func(ast.Expr) (string, bool)) (map[string]bool, bool) {
+	if f.ParamsArg == nil || call.Ellipsis.IsValid() {
+		return nil, false
+	}
+	params := map[string]bool{}
+	if len(call.Args) <= *f.ParamsArg {
+		return params, true
+	}
+
+	switch arg := call.Args[*f.ParamsArg].(type) {
+	case *ast.Ident:
+		if arg.Name == "nil" && arg.Obj == nil {
+			return params, true
+		}
+	case *ast.CompositeLit:
+		_, isMap := arg.Type.(*ast.MapType)
+		for _, elt := range arg.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				return nil, false
+			}
+			// Named types may be structs, only trust string literal keys.
+			if _, isLit := kv.Key.(*ast.BasicLit); !isMap && !isLit {
+				return nil, false
+			}
+			name, ok := eval(kv.Key)
+			if !ok {
+				return nil, false
+			}
+			params[name] = true
+		}
+		return params, true
+	}
+	return nil, false
+}
+
+// fileImports maps the local names of the imports of a file to their package
+// paths.
+func fileImports(f *ast.File) map[string]string {
+	imports := map[string]string{}
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(importPath)
+		if isMajorVersionSuffix(name) {
+			name = path.Base(path.Dir(importPath))
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		imports[name] = importPath
+	}
+	return imports
+}
+
+func isMajorVersionSuffix(name string) bool {
+	if !strings.HasPrefix(name, "v") || len(name) < 2 {
+		return false
+	}
+	_, err := strconv.Atoi(name[1:])
+	return err == nil
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseCalls(t *testing.T, src string) (*ast.File, []*ast.CallExpr) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	require.NoError(t, err)
+	var calls []*ast.CallExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			calls = append(calls, call)
+		}
+		return true
+	})
+	return f, calls
+}
+
+func TestFuncRegistryKeyArg(t *testing.T) {
+	src := `package app
+
+import (
+	m "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/shared/i18n"
+)
+
+func f() {
+	i18n.T("first")
+	m.NewAppError("where", "second", nil, "", 0)
+	c.T("third")
+	other.NewAppError("where", "fourth")
+}
+`
+	for name, test := range map[string]struct {
+		Funcs    []translationFunc
+		Expected []string
+	}{
+		"Defaults": {
+			Funcs:    defaultTranslationFuncs,
+			Expected: []string{`"first"`, `"second"`, `"third"`, `"fourth"`},
+		},
+		"Package qualifier resolves aliased imports": {
+			Funcs:    []translationFunc{{Name: "NewAppError", Package: "github.com/mattermost/mattermost-server/v6/model", KeyArg: 1}},
+			Expected: []string{"", `"second"`, "", ""},
+		},
+		"Receiver qualifier only matches method calls": {
+			Funcs:    []translationFunc{{Name: "T", Receiver: "Context", KeyArg: 0}},
+			Expected: []string{"", "", `"third"`, ""},
+		},
+		"Receiver type is not checked without type information": {
+			Funcs:    []translationFunc{{Name: "T", Receiver: "*Session", KeyArg: 0}},
+			Expected: []string{"", "", `"third"`, ""},
+		},
+		"Key argument out of range": {
+			Funcs:    []translationFunc{{Name: "NewAppError", KeyArg: 2}},
+			Expected: []string{"", "nil", "", ""},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, calls := parseCalls(t, src)
+			registry := newFuncRegistry(test.Funcs)
+			imports := fileImports(f)
+			require.Len(t, calls, len(test.Expected))
+			for i, call := range calls {
+				var arg ast.Expr
+				if fn := registry.match(call, imports); fn != nil {
+					arg = fn.keyArg(call)
+				}
+				switch v := arg.(type) {
+				case *ast.BasicLit:
+					assert.Equal(t, test.Expected[i], v.Value)
+				case *ast.Ident:
+					assert.Equal(t, test.Expected[i], v.Name)
+				default:
+					assert.Equal(t, test.Expected[i], "")
+				}
+			}
+		})
+	}
+}
+
+func TestTranslationFuncParams(t *testing.T) {
+	_, calls := parseCalls(t, `package app
+
+func f(params map[string]interface{}, args []interface{}) {
+	T("literal", map[string]interface{}{"Name": n, "Count": c})
+	T("none")
+	T("nil", nil)
+	T("variable", params)
+	T("named", model.StringInterface{"Name": n})
+	T("struct", Params{Name: n})
+	T("ellipsis", args...)
+}
+`)
+	fn := &defaultTranslationFuncs[0]
+	eval := func(expr ast.Expr) (string, bool) {
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok {
+			return "", false
+		}
+		return strings.Trim(lit.Value, "\""), true
+	}
+
+	expected := []map[string]bool{
+		{"Name": true, "Count": true},
+		{},
+		{},
+		nil,
+		{"Name": true},
+		nil,
+		nil,
+	}
+	require.Len(t, calls, len(expected))
+	for i, call := range calls {
+		params, ok := fn.params(call, eval)
+		assert.Equal(t, expected[i] != nil, ok, "call %d", i)
+		if expected[i] != nil {
+			assert.Equal(t, expected[i], params, "call %d", i)
+		}
+	}
+}
+
+func TestLoadI18nConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmgotool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("Missing default file uses built-in defaults", func(t *testing.T) {
+		config, err := loadI18nConfig(dir, "")
+		require.NoError(t, err)
+		assert.Equal(t, defaultTranslationFuncs, config.Functions)
+	})
+
+	t.Run("Missing explicit file is an error", func(t *testing.T) {
+		_, err := loadI18nConfig(dir, filepath.Join(dir, "missing.json"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Functions from file replace the defaults", func(t *testing.T) {
+		data := `{"functions": [{"name": "Tr", "key_arg": 1}]}`
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, i18nConfigFileName), []byte(data), 0600))
+		config, err := loadI18nConfig(dir, "")
+		require.NoError(t, err)
+		assert.Equal(t, []translationFunc{{Name: "Tr", KeyArg: 1}}, config.Functions)
+	})
+
+	t.Run("Invalid entries are rejected", func(t *testing.T) {
+		data := `{"functions": [{"name": "Tr", "key_arg": -1}]}`
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, i18nConfigFileName), []byte(data), 0600))
+		_, err := loadI18nConfig(dir, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Lint section overrides the given fields", func(t *testing.T) {
+		data := `{"lint": {"app_error_suffix": ".error", "package_prefixes": {"api4": ["api."]}, "disabled_rules": ["enterprise-key"]}}`
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, i18nConfigFileName), []byte(data), 0600))
+		config, err := loadI18nConfig(dir, "")
+		require.NoError(t, err)
+		assert.Equal(t, lintConfig{
+			KeyPattern:        defaultI18nConfig().Lint.KeyPattern,
+			AppErrorFunctions: []string{"NewAppError"},
+			AppErrorSuffix:    ".error",
+			PackagePrefixes:   map[string][]string{"api4": {"api."}},
+			DisabledRules:     []string{"enterprise-key"},
+		}, config.Lint)
+	})
+
+	t.Run("English copies allowlist is read", func(t *testing.T) {
+		data := `{"english_copies": {"allowed_keys": ["about.title"], "allowed_strings": ["Mattermost"]}}`
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, i18nConfigFileName), []byte(data), 0600))
+		config, err := loadI18nConfig(dir, "")
+		require.NoError(t, err)
+		assert.Equal(t, englishCopiesConfig{AllowedKeys: []string{"about.title"}, AllowedStrings: []string{"Mattermost"}, IgnoredLocales: []string{"en-*"}}, config.EnglishCopies)
+	})
+
+	t.Run("English copies ignored locales replace the default", func(t *testing.T) {
+		data := `{"english_copies": {"ignored_locales": []}}`
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, i18nConfigFileName), []byte(data), 0600))
+		config, err := loadI18nConfig(dir, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{}, config.EnglishCopies.IgnoredLocales)
+	})
+
+	t.Run("Invalid lint entries are rejected", func(t *testing.T) {
+		for _, data := range []string{`{"lint": {"key_pattern": "("}}`, `{"lint": {"disabled_rules": ["unknown"]}}`, `{"english_copies": {"ignored_locales": ["en-["]}}`} {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, i18nConfigFileName), []byte(data), 0600))
+			_, err := loadI18nConfig(dir, "")
+			assert.Error(t, err, data)
+		}
+	})
+}
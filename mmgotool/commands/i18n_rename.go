@@ -0,0 +1,281 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var RenameCmd = &cobra.Command{
+	Use:   "rename <old-key> <new-key>",
+	Short: "Rename translation keys",
+	Long:  "Rename a translation key, or every key starting with a prefix, in the translation calls of the source code and in every locale file, keeping the existing translations. Keys built from constants cannot be renamed, nothing is changed when a renamed key is used that way unless --force is set.",
+	Example: `  i18n rename api.user.save.app_error app.user.save.app_error
+  i18n rename --prefix api.oauth. app.oauth.`,
+	Args: cobra.ExactArgs(2),
+	RunE: renameCmdF,
+}
+
+func init() {
+	RenameCmd.Flags().Bool("prefix", false, "Rename every key starting with <old-key>, replacing the prefix with <new-key>")
+	RenameCmd.Flags().Bool("dry-run", false, "Run without applying changes")
+	RenameCmd.Flags().Bool("force", false, "Rename even when some calls build the key from constants, leaving them to update manually")
+	addExtractFlags(RenameCmd)
+
+	I18nCmd.AddCommand(RenameCmd)
+}
+
+// keyRenamer renames the key From, or every key starting with From when
+// Prefix is set, to To.
+type keyRenamer struct {
+	From   string
+	To     string
+	Prefix bool
+}
+
+// rename returns the new name of id and whether it is renamed.
+func (k keyRenamer) rename(id string) (string, bool) {
+	if k.Prefix && strings.HasPrefix(id, k.From) {
+		return k.To + strings.TrimPrefix(id, k.From), true
+	}
+	if id == k.From {
+		return k.To, true
+	}
+	return id, false
+}
+
+// renameItems renames the items of a locale file in place, keeping their
+// translations and order. It returns the number of renamed items and fails
+// when a new key collides with another one.
+func renameItems(items []Item, k keyRenamer) (int, error) {
+	renamed := 0
+	ids := map[string]bool{}
+	for i := range items {
+		if id, ok := k.rename(items[i].ID); ok {
+			items[i].ID = id
+			renamed++
+		}
+		if ids[items[i].ID] {
+			return 0, fmt.Errorf("key %s already exists", items[i].ID)
+		}
+		ids[items[i].ID] = true
+	}
+	return renamed, nil
+}
+
+// sourceEdit replaces the bytes of a source file between the Start and End
+// offsets with Text.
+type sourceEdit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// renameSourceKeys rewrites the string literal keys of the calls of a source
+// file. It returns the new source code, the number of renamed keys and the
+// calls whose key is not a string literal, which must be updated manually.
+func renameSourceKeys(filename string, src []byte, calls []translationCall, k keyRenamer, registry *funcRegistry) ([]byte, int, []string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	lines := map[int]bool{}
+	for _, call := range calls {
+		lines[call.Line] = true
+	}
+
+	imports := fileImports(f)
+	var edits []sourceEdit
+	var manual []string
+	renamed := map[int]int{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		fn := registry.match(call, imports)
+		line := fset.Position(call.Pos()).Line
+		if fn == nil || !lines[line] {
+			return true
+		}
+		lit, ok := fn.keyArg(call).(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		id, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		newID, ok := k.rename(id)
+		if !ok {
+			return true
+		}
+		text := strconv.Quote(newID)
+		if strings.HasPrefix(lit.Value, "`") && !strings.ContainsAny(newID, "`\r") {
+			text = "`" + newID + "`"
+		}
+		edits = append(edits, sourceEdit{
+			Start: fset.Position(lit.Pos()).Offset,
+			End:   fset.Position(lit.End()).Offset,
+			Text:  text,
+		})
+		renamed[line]++
+		return true
+	})
+
+	// Calls of a line are rewritten together, the ones left on it hold keys
+	// built from constants or expressions.
+	for _, call := range calls {
+		if renamed[call.Line] > 0 {
+			renamed[call.Line]--
+			continue
+		}
+		manual = append(manual, fmt.Sprintf("%s: %s uses %s, update it manually", call.keyLocation, call.Func, call.ID))
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+	var buffer bytes.Buffer
+	last := 0
+	for _, edit := range edits {
+		buffer.Write(src[last:edit.Start])
+		buffer.WriteString(edit.Text)
+		last = edit.End
+	}
+	buffer.Write(src[last:])
+	return buffer.Bytes(), len(edits), manual, nil
+}
+
+func renameCmdF(command *cobra.Command, args []string) error {
+	prefix, err := command.Flags().GetBool("prefix")
+	if err != nil {
+		return errors.New("invalid prefix parameter")
+	}
+	dryRun, err := command.Flags().GetBool("dry-run")
+	if err != nil {
+		return errors.New("invalid dry-run parameter")
+	}
+	force, err := command.Flags().GetBool("force")
+	if err != nil {
+		return errors.New("invalid force parameter")
+	}
+	k := keyRenamer{From: args[0], To: args[1], Prefix: prefix}
+	if k.From == "" || k.To == "" || k.From == k.To {
+		return errors.New("please specify two different keys")
+	}
+	opts, err := getExtractOptions(command)
+	if err != nil {
+		return err
+	}
+
+	// Every change is computed before writing, so that a collision leaves
+	// all the files untouched.
+	type fileChange struct {
+		Filename string
+		Renamed  int
+		Items    []Item
+		Source   []byte
+	}
+	var changes []fileChange
+	localeDir := path.Join(opts.TranslationDir, "i18n")
+	localeFiles, err := getLocaleFiles(localeDir)
+	if err != nil {
+		return err
+	}
+	for _, file := range append([]string{baseLocaleFile}, localeFiles...) {
+		filename := path.Join(localeDir, file)
+		items, err2 := loadItems(filename)
+		if err2 != nil {
+			return err2
+		}
+		renamed, err2 := renameItems(items, k)
+		if err2 != nil {
+			command.SilenceUsage = true
+			return fmt.Errorf("%s: %v", filename, err2)
+		}
+		if renamed > 0 {
+			changes = append(changes, fileChange{Filename: filename, Renamed: renamed, Items: items})
+		}
+	}
+
+	extracted, err := extractSrcStrings(opts)
+	if err != nil {
+		command.SilenceUsage = true
+		return err
+	}
+	var files []string
+	callsByFile := map[string][]translationCall{}
+	for _, call := range extracted.Calls {
+		if _, ok := k.rename(call.ID); !ok {
+			continue
+		}
+		if _, ok := callsByFile[call.File]; !ok {
+			files = append(files, call.File)
+		}
+		callsByFile[call.File] = append(callsByFile[call.File], call)
+	}
+	registry := newFuncRegistry(opts.Config.Functions)
+	var manual []string
+	for _, filename := range files {
+		src, err2 := ioutil.ReadFile(filename)
+		if err2 != nil {
+			return err2
+		}
+		src, renamed, fileManual, err2 := renameSourceKeys(filename, src, callsByFile[filename], k, registry)
+		if err2 != nil {
+			return err2
+		}
+		manual = append(manual, fileManual...)
+		if renamed > 0 {
+			changes = append(changes, fileChange{Filename: filename, Renamed: renamed, Source: src})
+		}
+	}
+	if len(changes) == 0 && len(manual) == 0 {
+		command.SilenceUsage = true
+		return fmt.Errorf("no translation key matches %s", k.From)
+	}
+	// The locale files must not be renamed while the code still uses the
+	// old keys, the next i18n check would fail.
+	if len(manual) > 0 && !force {
+		for _, m := range manual {
+			fmt.Println(m)
+		}
+		command.SilenceUsage = true
+		calls := fmt.Sprintf("%d calls use", len(manual))
+		if len(manual) == 1 {
+			calls = "1 call uses"
+		}
+		return fmt.Errorf("%s a renamed key built from constants, update them first or use --force", calls)
+	}
+
+	for _, change := range changes {
+		if !dryRun {
+			if change.Source != nil {
+				err = writeFileAtomically(change.Filename, change.Source, 0644)
+			} else {
+				err = writeLocaleFile(change.Filename, change.Items)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Printf("%s: %d keys renamed\n", change.Filename, change.Renamed)
+	}
+	for _, m := range manual {
+		fmt.Println(m)
+	}
+	return nil
+}
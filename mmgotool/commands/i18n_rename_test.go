@@ -0,0 +1,130 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRenamer(t *testing.T) {
+	k := keyRenamer{From: "api.user.", To: "app.user."}
+	_, ok := k.rename("api.user.save")
+	assert.False(t, ok)
+	id, ok := k.rename("api.user.")
+	assert.True(t, ok)
+	assert.Equal(t, "app.user.", id)
+
+	k.Prefix = true
+	id, ok = k.rename("api.user.save")
+	assert.True(t, ok)
+	assert.Equal(t, "app.user.save", id)
+	_, ok = k.rename("api.team.save")
+	assert.False(t, ok)
+}
+
+func TestRenameItems(t *testing.T) {
+	items := []Item{
+		{ID: "api.user.save", Translation: json.RawMessage(`"Save"`)},
+		{ID: "api.team.save", Translation: json.RawMessage(`{"one": "Team", "other": "Teams"}`)},
+		{ID: "api.user.get", Translation: json.RawMessage(`"Get"`)},
+	}
+	renamed, err := renameItems(items, keyRenamer{From: "api.user.", To: "app.user.", Prefix: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, renamed)
+	assert.Equal(t, []Item{
+		{ID: "app.user.save", Translation: json.RawMessage(`"Save"`)},
+		{ID: "api.team.save", Translation: json.RawMessage(`{"one": "Team", "other": "Teams"}`)},
+		{ID: "app.user.get", Translation: json.RawMessage(`"Get"`)},
+	}, items)
+
+	_, err = renameItems(items, keyRenamer{From: "app.user.get", To: "app.user.save"})
+	assert.EqualError(t, err, "key app.user.save already exists")
+}
+
+func TestRenameSourceKeys(t *testing.T) {
+	src := `package app
+
+const saveKey = "api.user.save"
+
+func f(c *Context) {
+	c.T("api.user.save", map[string]interface{}{"Name": name})
+	c.T(saveKey); c.T(` + "`api.user.save`" + `)
+	c.T("api.team.save")
+	other("api.user.save")
+}
+`
+	calls := []translationCall{
+		{keyLocation: keyLocation{File: "app/a.go", Line: 6}, ID: "api.user.save", Func: "c.T"},
+		{keyLocation: keyLocation{File: "app/a.go", Line: 7}, ID: "api.user.save", Func: "c.T"},
+		{keyLocation: keyLocation{File: "app/a.go", Line: 7}, ID: "api.user.save", Func: "c.T"},
+	}
+	k := keyRenamer{From: "api.user.save", To: "app.user.save"}
+
+	renamedSrc, renamed, manual, err := renameSourceKeys("app/a.go", []byte(src), calls, k, newFuncRegistry(defaultTranslationFuncs))
+	require.NoError(t, err)
+	assert.Equal(t, 2, renamed)
+	assert.Equal(t, []string{"app/a.go:7: c.T uses api.user.save, update it manually"}, manual)
+	assert.Equal(t, `package app
+
+const saveKey = "api.user.save"
+
+func f(c *Context) {
+	c.T("app.user.save", map[string]interface{}{"Name": name})
+	c.T(saveKey); c.T(`+"`app.user.save`"+`)
+	c.T("api.team.save")
+	other("api.user.save")
+}
+`, string(renamedSrc))
+
+	_, _, _, err = renameSourceKeys("app/a.go", []byte("package app\nfunc f( {"), calls, k, newFuncRegistry(defaultTranslationFuncs))
+	assert.Error(t, err)
+}
+
+func TestRenameConstantKeys(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"i18n/en.json": `[{"id": "api.user.save", "translation": "Save"}]` + "\n",
+		"app/a.go": `package app
+
+const saveKey = "api.user.save"
+
+func f(c *Context) {
+	c.T(saveKey)
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	rename := func(force bool) error {
+		command := &cobra.Command{}
+		command.Flags().Bool("prefix", false, "")
+		command.Flags().Bool("dry-run", false, "")
+		command.Flags().Bool("force", force, "")
+		addExtractFlags(command)
+		require.NoError(t, command.Flags().Set("mattermost-dir", dir))
+		require.NoError(t, command.Flags().Set("enterprise-dir", ""))
+		require.NoError(t, command.Flags().Set("portal-dir", ""))
+		require.NoError(t, command.Flags().Set("no-cache", "true"))
+		require.NoError(t, command.Flags().Set("skip-dynamic", "true"))
+		return renameCmdF(command, []string{"api.user.save", "app.user.save"})
+	}
+	readBase := func() string {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "i18n", baseLocaleFile))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.EqualError(t, rename(false), "1 call uses a renamed key built from constants, update them first or use --force")
+	assert.Contains(t, readBase(), `"api.user.save"`)
+
+	require.NoError(t, rename(true))
+	assert.Contains(t, readBase(), `"app.user.save"`)
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSARIF = "sarif"
+)
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityNote    = "note"
+)
+
+// findingRules describes the rules of the findings reported by the i18n
+// commands, by rule id.
+var findingRules = map[string]string{
+	"added-key":               "Translation key used in the source code is missing from en.json",
+	"removed-key":             "Translation key of en.json is not used in the source code",
+	"unmatched-dynamic-key":   "Dynamic key entry matches no translation key of en.json",
+	"dynamic-key":             "Translation key is not known at compile time",
+	"rejected-call":           "Call does not resolve to a translation function",
+	"empty-source":            "Translation source string is empty",
+	"empty-translation":       "Translation is empty",
+	"orphaned-key":            "Translation key of a locale file is missing from en.json",
+	"placeholder-mismatch":    "Translation placeholders differ from the source string",
+	"invalid-template":        "Translation is not a valid template",
+	"params-mismatch":         "Template parameters passed differ from the source string placeholders",
+	"invalid-plural":          "Translation plural forms are invalid",
+	"key-pattern":             "Translation key does not match the configured pattern",
+	"app-error-suffix":        "Translation key of an application error lacks the required suffix",
+	"enterprise-key":          "Enterprise translation key is used outside of the enterprise source code",
+	"package-prefix":          "Translation key does not start with a prefix configured for the package using it",
+	"unrelated-source-change": "Translation key added to or removed from en.json without a matching source code change",
+	"english-copy":            "Translation is a copy of the English source string",
+}
+
+// finding is a problem reported by an i18n command.
+type finding struct {
+	RuleID   string `json:"rule_id"`
+	Severity string `json:"severity"`
+	Key      string `json:"key,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// at returns the finding located at loc.
+func (f finding) at(loc keyLocation) finding {
+	f.File = loc.File
+	f.Line = loc.Line
+	return f
+}
+
+func addFormatFlag(command *cobra.Command) {
+	command.Flags().String("format", formatText, "Output format, one of: text, json, sarif")
+}
+
+// reporter prints the findings of a command in the format chosen with the
+// format flag. Text is printed as findings are reported, the other formats
+// are printed at once by flush.
+type reporter struct {
+	format   string
+	out      io.Writer
+	findings []finding
+	lines    map[string]map[string]int
+}
+
+func newReporter(command *cobra.Command) (*reporter, error) {
+	format, err := command.Flags().GetString("format")
+	if err != nil {
+		return nil, errors.New("invalid format parameter")
+	}
+	switch format {
+	case formatText, formatJSON, formatSARIF:
+	default:
+		return nil, fmt.Errorf("invalid format %q, must be one of: text, json, sarif", format)
+	}
+	return &reporter{format: format, out: os.Stdout, lines: map[string]map[string]int{}}, nil
+}
+
+func (r *reporter) isText() bool {
+	return r.format == formatText
+}
+
+// report prints text, unless empty, in the text format, otherwise it
+// records findings.
+func (r *reporter) report(text string, findings ...finding) {
+	if r.isText() {
+		if text != "" {
+			fmt.Fprintln(r.out, text)
+		}
+		return
+	}
+	r.findings = append(r.findings, findings...)
+}
+
+// keyLine returns the line of the translation id in the locale file
+// filename. Locale files are only read for the machine readable formats, 0
+// is returned otherwise or when the id is not found.
+func (r *reporter) keyLine(filename, id string) int {
+	if r.isText() {
+		return 0
+	}
+	lines, ok := r.lines[filename]
+	if !ok {
+		data, err := ioutil.ReadFile(filename)
+		if err == nil {
+			lines = translationLines(data)
+		}
+		r.lines[filename] = lines
+	}
+	return lines[id]
+}
+
+// flush prints the recorded findings in the json or sarif format.
+func (r *reporter) flush() error {
+	var report interface{}
+	switch r.format {
+	case formatJSON:
+		findings := r.findings
+		if findings == nil {
+			findings = []finding{}
+		}
+		report = findings
+	case formatSARIF:
+		report = newSarifLog(r.findings)
+	default:
+		return nil
+	}
+	encoder := json.NewEncoder(r.out)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(report)
+}
+
+// translationLines maps the ids of a locale file to the line they are
+// declared at.
+func translationLines(data []byte) map[string]int {
+	lines := map[string]int{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	expectKey := false
+	key := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return lines
+		}
+		if delim, ok := token.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+				expectKey = depth == 2 && delim == '{'
+			} else {
+				depth--
+				expectKey = depth == 2
+			}
+			continue
+		}
+		if depth != 2 {
+			continue
+		}
+		if expectKey {
+			key, _ = token.(string)
+			expectKey = false
+			continue
+		}
+		if id, ok := token.(string); ok && key == "id" {
+			lines[id] = bytes.Count(data[:decoder.InputOffset()], []byte("\n")) + 1
+		}
+		expectKey = true
+	}
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// newSarifLog converts findings to a SARIF 2.1.0 log, listing the rules
+// they refer to.
+func newSarifLog(findings []finding) sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "mmgotool",
+			InformationURI: "https://github.com/mattermost/mattermost-utilities",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIDs := map[string]bool{}
+	for _, f := range findings {
+		ruleIDs[f.RuleID] = true
+		message := f.Message
+		if f.Key != "" {
+			message = f.Key + ": " + message
+		}
+		result := sarifResult{RuleID: f.RuleID, Level: f.Severity, Message: sarifMessage{Text: message}}
+		if f.File != "" {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(filepath.Clean(f.File))},
+			}}
+			if f.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line}
+			}
+			result.Locations = []sarifLocation{location}
+		}
+		run.Results = append(run.Results, result)
+	}
+	var ids []string
+	for id := range ruleIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: findingRules[id]}})
+	}
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslationLines(t *testing.T) {
+	data := []byte(`[
+  {
+    "id": "first",
+    "translation": {
+      "one": "One",
+      "id": "not an id"
+    }
+  },
+  {"translation": "Second", "id": "second"},
+  {
+    "id": "third",
+    "translation": ["id", "nested"]
+  }
+]`)
+	assert.Equal(t, map[string]int{"first": 3, "second": 9, "third": 11}, translationLines(data))
+	assert.Empty(t, translationLines([]byte("not json")))
+}
+
+func TestReporter(t *testing.T) {
+	findings := []finding{
+		{RuleID: "removed-key", Severity: severityError, Key: "old.key", File: "i18n/en.json", Line: 3, Message: "translation key not used in the source code"},
+		{RuleID: "dynamic-key", Severity: severityWarning, File: "./app/a.go", Message: "translation key of c.T(key) not known at compile time"},
+	}
+	report := func(format string) *bytes.Buffer {
+		var out bytes.Buffer
+		r := &reporter{format: format, out: &out, lines: map[string]map[string]int{}}
+		r.report("Removed: old.key", findings[0])
+		r.report("", findings[1])
+		require.NoError(t, r.flush())
+		return &out
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		assert.Equal(t, "Removed: old.key\n", report(formatText).String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var decoded []finding
+		require.NoError(t, json.Unmarshal(report(formatJSON).Bytes(), &decoded))
+		assert.Equal(t, findings, decoded)
+	})
+
+	t.Run("SARIF", func(t *testing.T) {
+		var decoded sarifLog
+		require.NoError(t, json.Unmarshal(report(formatSARIF).Bytes(), &decoded))
+		require.Len(t, decoded.Runs, 1)
+		run := decoded.Runs[0]
+		assert.Equal(t, "2.1.0", decoded.Version)
+		assert.Equal(t, []sarifRule{
+			{ID: "dynamic-key", ShortDescription: sarifMessage{Text: findingRules["dynamic-key"]}},
+			{ID: "removed-key", ShortDescription: sarifMessage{Text: findingRules["removed-key"]}},
+		}, run.Tool.Driver.Rules)
+		assert.Equal(t, []sarifResult{
+			{
+				RuleID:  "removed-key",
+				Level:   severityError,
+				Message: sarifMessage{Text: "old.key: translation key not used in the source code"},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "i18n/en.json"},
+					Region:           &sarifRegion{StartLine: 3},
+				}}},
+			},
+			{
+				RuleID:  "dynamic-key",
+				Level:   severityWarning,
+				Message: sarifMessage{Text: "translation key of c.T(key) not known at compile time"},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "app/a.go"},
+				}}},
+			},
+		}, run.Results)
+	})
+}
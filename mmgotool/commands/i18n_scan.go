@@ -0,0 +1,290 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// fileSummary is what extraction needs from a source file. It only depends
+// on the file content, so it is cached by content hash.
+type fileSummary struct {
+	// Generated is set for generated files, their constants are kept but
+	// their calls are skipped.
+	Generated bool                  `json:"generated,omitempty"`
+	Package   string                `json:"package"`
+	Consts    map[string]*constExpr `json:"consts,omitempty"`
+	Calls     []callSummary         `json:"calls,omitempty"`
+}
+
+// callSummary is a call to a translation function.
+type callSummary struct {
+	Line      int        `json:"line"`
+	Annotated bool       `json:"annotated,omitempty"`
+	Func      string     `json:"func"`
+	KeyExpr   string     `json:"key_expr"`
+	Key       *constExpr `json:"key"`
+	// Params are the template parameter names passed to the call, only set
+	// when KnownParams is true.
+	Params      []*constExpr `json:"params,omitempty"`
+	KnownParams bool         `json:"known_params,omitempty"`
+}
+
+// summarizeFile parses the source of a file and collects its constants and
+// calls to translation functions.
+func summarizeFile(filename string, src []byte, registry *funcRegistry) (*fileSummary, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := fileImports(f)
+	summary := &fileSummary{Package: f.Name.Name, Consts: fileConsts(f, imports)}
+	if ast.IsGenerated(f) {
+		summary.Generated = true
+		return summary, nil
+	}
+	annotated := annotatedLines(fset, f)
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		fn := registry.match(call, imports)
+		if fn == nil {
+			return true
+		}
+		line := fset.Position(call.Pos()).Line
+		arg := fn.keyArg(call)
+		c := callSummary{
+			Line:      line,
+			Annotated: annotated.covers(line),
+			Func:      types.ExprString(call.Fun),
+			KeyExpr:   types.ExprString(arg),
+			Key:       newConstExpr(arg, imports),
+		}
+		// Collect the parameter name expressions, they are evaluated with
+		// the key.
+		_, c.KnownParams = fn.params(call, func(expr ast.Expr) (string, bool) {
+			c.Params = append(c.Params, newConstExpr(expr, imports))
+			return "", true
+		})
+		if !c.KnownParams {
+			c.Params = nil
+		}
+		summary.Calls = append(summary.Calls, c)
+		return true
+	})
+	return summary, nil
+}
+
+// scannedFile is the outcome of scanning a single file. Summary is nil when
+// the file is excluded by its build constraints.
+type scannedFile struct {
+	Path    string
+	Summary *fileSummary
+	Err     error
+}
+
+// scanFiles summarizes the files matching the build tags using jobs
+// concurrent workers, or one per CPU when jobs is not positive. Summaries of
+// unchanged files are taken from cache when it is not nil. The result follows
+// the order of files.
+func scanFiles(files []string, jobs int, tags buildTags, registry *funcRegistry, cache *scanCache) []scannedFile {
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+	scanned := make([]scannedFile, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				scanned[i] = scanFile(files[i], tags, registry, cache)
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return scanned
+}
+
+func scanFile(filename string, tags buildTags, registry *funcRegistry, cache *scanCache) scannedFile {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return scannedFile{Path: filename, Err: err}
+	}
+	// Files with a broken header are parsed anyway to report a precise
+	// syntax error.
+	if match, err := tags.match(filename, src); err == nil && !match {
+		return scannedFile{Path: filename}
+	}
+	sum := sha256.Sum256(src)
+	hash := hex.EncodeToString(sum[:])
+	if summary, ok := cache.get(hash); ok {
+		return scannedFile{Path: filename, Summary: summary}
+	}
+	summary, err := summarizeFile(filename, src, registry)
+	if err != nil {
+		return scannedFile{Path: filename, Err: err}
+	}
+	cache.put(hash, summary)
+	return scannedFile{Path: filename, Summary: summary}
+}
+
+// knownOS and knownArch are the GOOS and GOARCH values of go/build, unixOS
+// those satisfying the "unix" constraint.
+var (
+	knownOS = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js",
+		"linux", "nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos"}
+	knownArch = []string{"386", "amd64", "amd64p32", "arm", "armbe", "arm64", "arm64be", "loong64", "mips",
+		"mipsle", "mips64", "mips64le", "mips64p32", "mips64p32le", "ppc", "ppc64", "ppc64le", "riscv",
+		"riscv64", "s390", "s390x", "sparc", "sparc64", "wasm"}
+	unixOS = map[string]bool{"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "linux": true, "netbsd": true, "openbsd": true, "solaris": true}
+	// impliedOS are the operating systems also satisfying the constraint of
+	// another one.
+	impliedOS = map[string]string{"android": "linux", "illumos": "solaris", "ios": "darwin"}
+)
+
+// buildTags selects the source files to scan. Files built for any known
+// platform are scanned, so the keys do not depend on the host running the
+// extraction, while the other build tags must be among the given ones.
+type buildTags map[string]bool
+
+func newBuildTags(tags []string) buildTags {
+	b := buildTags{}
+	for _, tag := range tags {
+		b[tag] = true
+	}
+	return b
+}
+
+// match tells whether the file named filename, holding src, is built for some
+// platform according to its name and build constraints.
+func (b buildTags) match(filename string, src []byte) (bool, error) {
+	expr, err := fileConstraint(src)
+	if err != nil {
+		return false, err
+	}
+	nameOS, nameArch := fileOSArch(filename)
+	for _, goos := range knownOS {
+		if nameOS != "" && goos != nameOS && impliedOS[goos] != nameOS {
+			continue
+		}
+		for _, goarch := range knownArch {
+			if nameArch != "" && goarch != nameArch {
+				continue
+			}
+			if expr == nil {
+				return true, nil
+			}
+			for _, cgo := range []bool{false, true} {
+				ok := expr.Eval(func(tag string) bool {
+					switch {
+					case tag == goos || tag == goarch || tag == impliedOS[goos]:
+						return true
+					case tag == "unix":
+						return unixOS[goos]
+					case tag == "cgo":
+						return cgo
+					case tag == "gc" || strings.HasPrefix(tag, "go1."):
+						return true
+					}
+					return b[tag]
+				})
+				if ok {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// fileConstraint returns the build constraint of the header of src, nil when
+// there is none. A //go:build line takes precedence over the // +build ones.
+func fileConstraint(src []byte) (constraint.Expr, error) {
+	var goBuild constraint.Expr
+	var plusBuild []constraint.Expr
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+		if !constraint.IsGoBuild(line) && !constraint.IsPlusBuild(line) {
+			continue
+		}
+		expr, err := constraint.Parse(line)
+		if err != nil {
+			return nil, err
+		}
+		if constraint.IsGoBuild(line) {
+			goBuild = expr
+		} else {
+			plusBuild = append(plusBuild, expr)
+		}
+	}
+	if goBuild != nil || len(plusBuild) == 0 {
+		return goBuild, nil
+	}
+	expr := plusBuild[0]
+	for _, x := range plusBuild[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: x}
+	}
+	return expr, nil
+}
+
+// fileOSArch returns the GOOS and GOARCH the name of a file restricts it to,
+// like "windows" and "amd64" for "a_windows_amd64.go".
+func fileOSArch(filename string) (string, string) {
+	name := strings.TrimSuffix(filepath.Base(filename), ".go")
+	name = strings.TrimSuffix(name, "_test")
+	parts := strings.Split(name, "_")
+	if len(parts) < 2 {
+		return "", ""
+	}
+	isOS := func(s string) bool { return sliceContains(knownOS, s) }
+	isArch := func(s string) bool { return sliceContains(knownArch, s) }
+	last := parts[len(parts)-1]
+	if len(parts) >= 3 && isOS(parts[len(parts)-2]) && isArch(last) {
+		return parts[len(parts)-2], last
+	}
+	if isOS(last) {
+		return last, ""
+	}
+	if isArch(last) {
+		return "", last
+	}
+	return "", ""
+}
+
+func sliceContains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSrcStringsScanning(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"go.mod": "module example.com/server\n\ngo 1.16\n",
+		"app/a.go": `package app
+
+import "example.com/server/model"
+
+const prefix = "app.channel."
+
+func f(dynamic string) {
+	c.T(prefix+"create", map[string]interface{}{"Name": name, model.ParamKey: 1})
+	model.NewAppError("f", model.MissingError, nil, "", 0)
+	c.T(prefix + dynamic)
+	c.T(dynamic) // i18n:dynamic
+}
+`,
+		"app/b.go": `package app
+
+func g(params map[string]interface{}) {
+	c.T("app.b", params)
+	c.T("app.b", nil)
+}
+`,
+		"model/errors.go": `package model
+
+const (
+	MissingError = "model.missing.app_error"
+	ParamKey     = "Count"
+)
+`,
+		"api/broken.go": `package api
+
+func h() {
+`,
+	})
+	defer os.RemoveAll(dir)
+	cacheDir, err := ioutil.TempDir("", "mmgotool-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	extract := func(jobs int, cacheDir string) *extractResult {
+		opts := &extractOptions{MattermostDir: dir, TranslationDir: dir, SkipDynamic: true, Config: defaultI18nConfig(), Jobs: jobs, CacheDir: cacheDir}
+		result, err := extractSrcStrings(opts)
+		require.IsType(t, extractErrors{}, err)
+		require.Len(t, err.(extractErrors), 1)
+		return result
+	}
+
+	serial := extract(1, "")
+	assert.Equal(t, []string{"app.b", "app.channel.create", "model.missing.app_error"}, serial.Usages.keys())
+	assert.Equal(t, []translationCall{
+		{keyLocation: keyLocation{File: filepath.Join(dir, "app/a.go"), Line: 8}, ID: "app.channel.create", Func: "c.T", Package: "app", Params: map[string]bool{"Name": true, "Count": true}, KnownParams: true},
+		{keyLocation: keyLocation{File: filepath.Join(dir, "app/a.go"), Line: 9}, ID: "model.missing.app_error", Func: "model.NewAppError", Package: "app", Params: map[string]bool{}, KnownParams: true},
+		{keyLocation: keyLocation{File: filepath.Join(dir, "app/b.go"), Line: 4}, ID: "app.b", Func: "c.T", Package: "app"},
+		{keyLocation: keyLocation{File: filepath.Join(dir, "app/b.go"), Line: 5}, ID: "app.b", Func: "c.T", Package: "app", Params: map[string]bool{}, KnownParams: true},
+	}, serial.Calls)
+	assert.Equal(t, []dynamicCall{
+		{keyLocation: keyLocation{File: filepath.Join(dir, "app/a.go"), Line: 10}, Func: "c.T", Key: "prefix + dynamic"},
+	}, serial.DynamicCalls)
+
+	t.Run("Concurrent scan matches the serial one", func(t *testing.T) {
+		assert.Equal(t, serial, extract(8, ""))
+	})
+
+	t.Run("Cached scan matches the serial one", func(t *testing.T) {
+		assert.Equal(t, serial, extract(4, cacheDir))
+		require.FileExists(t, filepath.Join(cacheDir, scanCacheFileName))
+
+		cache := loadScanCache(cacheDir, defaultI18nConfig().Functions)
+		assert.Len(t, cache.loaded, 3)
+		assert.Equal(t, serial, extract(4, cacheDir))
+	})
+
+	t.Run("Changed files are scanned again", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "model/errors.go"), []byte(`package model
+
+const (
+	MissingError = "model.renamed.app_error"
+	ParamKey     = "Count"
+)
+`), 0600))
+		result := extract(4, cacheDir)
+		assert.Equal(t, []string{"app.b", "app.channel.create", "model.renamed.app_error"}, result.Usages.keys())
+		assert.Equal(t, result, extract(1, ""))
+	})
+
+	t.Run("Cache of other translation functions is discarded", func(t *testing.T) {
+		cache := loadScanCache(cacheDir, []translationFunc{{Name: "T"}})
+		assert.Empty(t, cache.loaded)
+	})
+}
+
+func TestBuildTagsMatch(t *testing.T) {
+	tags := newBuildTags([]string{"enterprise"})
+	for _, tc := range []struct {
+		name     string
+		filename string
+		src      string
+		match    bool
+	}{
+		{"No constraint", "a.go", "package app\n", true},
+		{"Operating system suffix", "a_windows.go", "package app\n", true},
+		{"Architecture suffix", "a_linux_s390x.go", "package app\n", true},
+		{"Operating system constraint", "a.go", "//go:build darwin\n\npackage app\n", true},
+		{"Negated operating system", "a.go", "//go:build !linux && !cgo\n\npackage app\n", true},
+		{"Plus build lines", "a.go", "// +build darwin freebsd\n// +build enterprise\n\npackage app\n", true},
+		{"Given tag", "a.go", "//go:build enterprise\n\npackage app\n", true},
+		{"Missing tag", "a.go", "//go:build sourceavailable\n\npackage app\n", false},
+		{"Ignored file", "a.go", "//go:build ignore\n\npackage app\n", false},
+		{"Constraint contradicting the name", "a_windows.go", "//go:build unix\n\npackage app\n", false},
+		{"Implied operating system", "a_android.go", "//go:build linux\n\npackage app\n", true},
+		{"Constraint after the package clause", "a.go", "package app\n\n//go:build ignore\n", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			match, err := tags.match(tc.filename, []byte(tc.src))
+			require.NoError(t, err)
+			assert.Equal(t, tc.match, match)
+		})
+	}
+}
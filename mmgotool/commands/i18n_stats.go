@@ -0,0 +1,154 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+const formatTable = "table"
+const formatMarkdown = "markdown"
+
+var StatsCmd = &cobra.Command{
+	Use:     "stats",
+	Short:   "Report translation coverage",
+	Long:    "Report, for every locale file, how many of the i18n/en.json source strings are translated, missing or empty and how many of its keys are orphaned",
+	Example: "  i18n stats --format markdown --min-coverage 80",
+	RunE:    statsCmdF,
+}
+
+func init() {
+	addLocaleDirFlags(StatsCmd)
+	StatsCmd.Flags().String("format", formatTable, "Output format, one of: table, json, markdown")
+	StatsCmd.Flags().Float64("min-coverage", 0, "Fail when the coverage of a locale is below this percentage")
+
+	I18nCmd.AddCommand(StatsCmd)
+}
+
+// localeStats is the translation coverage of a locale file.
+type localeStats struct {
+	Locale     string `json:"locale"`
+	Total      int    `json:"total"`
+	Translated int    `json:"translated"`
+	Missing    int    `json:"missing"`
+	Empty      int    `json:"empty"`
+	// Orphaned counts the keys missing from the source file.
+	Orphaned int     `json:"orphaned"`
+	Coverage float64 `json:"coverage"`
+}
+
+// computeStats compares the translations of locale with the source strings.
+func computeStats(locale string, source []Translation, translations []Translation) localeStats {
+	stats := localeStats{Locale: locale, Total: len(source)}
+	byID := translationsByID(translations)
+	sourceIDs := map[string]bool{}
+	for _, src := range source {
+		sourceIDs[src.Id] = true
+		t, ok := byID[src.Id]
+		switch {
+		case !ok:
+			stats.Missing++
+		case isEmptyTranslation(t.Translation):
+			stats.Empty++
+		default:
+			stats.Translated++
+		}
+	}
+	for _, t := range translations {
+		if !sourceIDs[t.Id] {
+			stats.Orphaned++
+		}
+	}
+	if stats.Total > 0 {
+		stats.Coverage = float64(stats.Translated) * 100 / float64(stats.Total)
+	}
+	return stats
+}
+
+func printStatsTable(out io.Writer, stats []localeStats) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LOCALE\tTRANSLATED\tMISSING\tEMPTY\tORPHANED\tCOVERAGE")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.1f%%\n", s.Locale, s.Translated, s.Missing, s.Empty, s.Orphaned, s.Coverage)
+	}
+	return w.Flush()
+}
+
+func printStatsMarkdown(out io.Writer, stats []localeStats) {
+	fmt.Fprintln(out, "| Locale | Translated | Missing | Empty | Orphaned | Coverage |")
+	fmt.Fprintln(out, "|:-------|-----------:|--------:|------:|---------:|---------:|")
+	for _, s := range stats {
+		fmt.Fprintf(out, "| %s | %d | %d | %d | %d | %.1f%% |\n", s.Locale, s.Translated, s.Missing, s.Empty, s.Orphaned, s.Coverage)
+	}
+}
+
+func statsCmdF(command *cobra.Command, args []string) error {
+	format, err := command.Flags().GetString("format")
+	if err != nil {
+		return errors.New("invalid format parameter")
+	}
+	if format != formatTable && format != formatJSON && format != formatMarkdown {
+		return fmt.Errorf("invalid format %q, must be one of: table, json, markdown", format)
+	}
+	minCoverage, err := command.Flags().GetFloat64("min-coverage")
+	if err != nil {
+		return errors.New("invalid min-coverage parameter")
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	source, err := loadTranslations(path.Join(translationDir, baseLocaleFile))
+	if err != nil {
+		return err
+	}
+	files, err := getLocaleFiles(translationDir)
+	if err != nil {
+		return err
+	}
+
+	stats := []localeStats{}
+	var below []string
+	for _, file := range files {
+		translations, err2 := loadTranslations(path.Join(translationDir, file))
+		if err2 != nil {
+			return err2
+		}
+		s := computeStats(strings.TrimSuffix(file, path.Ext(file)), source, translations)
+		if s.Coverage < minCoverage {
+			below = append(below, s.Locale)
+		}
+		stats = append(stats, s)
+	}
+
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(stats); err != nil {
+			return err
+		}
+	case formatMarkdown:
+		printStatsMarkdown(os.Stdout, stats)
+	default:
+		if err = printStatsTable(os.Stdout, stats); err != nil {
+			return err
+		}
+	}
+
+	if len(below) > 0 {
+		command.SilenceUsage = true
+		return fmt.Errorf("coverage below %.1f%% for locales: %s", minCoverage, strings.Join(below, ", "))
+	}
+	return nil
+}
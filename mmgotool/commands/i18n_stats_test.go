@@ -0,0 +1,60 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStats(t *testing.T) {
+	source := []Translation{
+		{Id: "a", Translation: "A"},
+		{Id: "b", Translation: "B"},
+		{Id: "c", Translation: "C"},
+		{Id: "d", Translation: map[string]interface{}{"one": "D", "other": "Ds"}},
+	}
+	translations := []Translation{
+		{Id: "a", Translation: "A'"},
+		{Id: "b", Translation: ""},
+		{Id: "d", Translation: map[string]interface{}{"one": "", "other": "D's"}},
+		{Id: "orphan", Translation: "O"},
+	}
+
+	assert.Equal(t, localeStats{
+		Locale:     "fr",
+		Total:      4,
+		Translated: 2,
+		Missing:    1,
+		Empty:      1,
+		Orphaned:   1,
+		Coverage:   50,
+	}, computeStats("fr", source, translations))
+	assert.Equal(t, localeStats{Locale: "xx"}, computeStats("xx", nil, nil))
+}
+
+func TestPrintStats(t *testing.T) {
+	stats := []localeStats{
+		{Locale: "de", Total: 3, Translated: 3, Coverage: 100},
+		{Locale: "pt-BR", Total: 3, Translated: 1, Missing: 1, Empty: 1, Orphaned: 2, Coverage: 100.0 / 3},
+	}
+
+	var table bytes.Buffer
+	require.NoError(t, printStatsTable(&table, stats))
+	assert.Equal(t, `LOCALE  TRANSLATED  MISSING  EMPTY  ORPHANED  COVERAGE
+de      3           0        0      0         100.0%
+pt-BR   1           1        1      2         33.3%
+`, table.String())
+
+	var markdown bytes.Buffer
+	printStatsMarkdown(&markdown, stats)
+	assert.Equal(t, `| Locale | Translated | Missing | Empty | Orphaned | Coverage |
+|:-------|-----------:|--------:|------:|---------:|---------:|
+| de | 3 | 0 | 0 | 0 | 100.0% |
+| pt-BR | 1 | 1 | 1 | 2 | 33.3% |
+`, markdown.String())
+}
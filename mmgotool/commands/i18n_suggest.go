@@ -0,0 +1,376 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var SuggestCmd = &cobra.Command{
+	Use:   "suggest [locale...]",
+	Short: "Suggest translations from identical source strings",
+	Long:  "Suggest, for the untranslated keys of the locale files, the existing translation of another key whose i18n/en.json source string is identical, or only differs by case and whitespace",
+	Example: `  i18n suggest fr de --output suggestions.json
+  i18n suggest --apply suggestions.json`,
+	RunE: suggestCmdF,
+}
+
+func init() {
+	SuggestCmd.Flags().String("output", "", "Path to write the suggestions to for review")
+	SuggestCmd.Flags().Bool("write", false, "Add the suggestions to the locale files")
+	SuggestCmd.Flags().String("apply", "", "Path to reviewed suggestions to add to the locale files")
+	SuggestCmd.Flags().String("config", "", "Path to the i18n configuration file (defaults to "+i18nConfigFileName+" in the source code folder)")
+	addLocaleDirFlags(SuggestCmd)
+
+	I18nCmd.AddCommand(SuggestCmd)
+}
+
+// suggestion proposes the translation of a key found under another key with
+// the same source string.
+type suggestion struct {
+	Locale      string          `json:"locale"`
+	ID          string          `json:"id"`
+	Source      interface{}     `json:"source"`
+	Translation json.RawMessage `json:"translation"`
+	From        string          `json:"from"`
+	// Normalized is set when the source strings only match once normalized.
+	Normalized bool `json:"normalized,omitempty"`
+}
+
+func (s suggestion) String() string {
+	match := ""
+	if s.Normalized {
+		match = ", normalized source"
+	}
+	return fmt.Sprintf("%s.json: %s: %s (from %s%s)", s.Locale, s.ID, s.Translation, s.From, match)
+}
+
+// translationMemory indexes the keys of en.json by source string.
+type translationMemory struct {
+	source []Translation
+	// exact and normalized map the signatures of the source strings to
+	// their keys, in the order of en.json.
+	exact      map[string][]string
+	normalized map[string][]string
+}
+
+func newTranslationMemory(source []Translation) *translationMemory {
+	m := &translationMemory{source: source, exact: map[string][]string{}, normalized: map[string][]string{}}
+	for _, t := range source {
+		if isEmptyTranslation(t.Translation) {
+			continue
+		}
+		exact, normalized := sourceSignature(t.Translation, false), sourceSignature(t.Translation, true)
+		m.exact[exact] = append(m.exact[exact], t.Id)
+		m.normalized[normalized] = append(m.normalized[normalized], t.Id)
+	}
+	return m
+}
+
+// sourceSignature returns a string identifying the forms of a source
+// string. Normalized signatures ignore case and repeated whitespace outside
+// of the template actions.
+func sourceSignature(value interface{}, normalize bool) string {
+	forms := translationForms(value)
+	names := make([]string, 0, len(forms))
+	for form := range forms {
+		names = append(names, form)
+	}
+	sort.Strings(names)
+	var signature strings.Builder
+	for _, form := range names {
+		text := forms[form]
+		if normalize {
+			text = normalizeSource(text)
+		}
+		signature.WriteString(form + "\x00" + text + "\x00")
+	}
+	return signature.String()
+}
+
+func normalizeSource(text string) string {
+	var normalized strings.Builder
+	last := 0
+	for _, loc := range templateActionRegexp.FindAllStringIndex(text, -1) {
+		normalized.WriteString(strings.ToLower(text[last:loc[0]]))
+		normalized.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	normalized.WriteString(strings.ToLower(text[last:]))
+	return strings.Join(strings.Fields(normalized.String()), " ")
+}
+
+// suggest returns the suggestions for the keys of en.json missing from, or
+// empty in, the translations of locale. Translations copied from English
+// are never suggested, unless the locale is ignored by the copy checker. Among several candidates, the translation shared by
+// the most keys wins, then the one of the first key.
+func (m *translationMemory) suggest(locale string, translations []Translation, checker *copyChecker) ([]suggestion, error) {
+	byID := translationsByID(translations)
+	checkCopies := !checker.ignoresLocale(locale)
+	translated := func(id string) (interface{}, bool) {
+		t, ok := byID[id]
+		if !ok || isEmptyTranslation(t.Translation) || (checkCopies && checker.isCopy(id, t.Translation)) {
+			return nil, false
+		}
+		return t.Translation, true
+	}
+
+	var suggestions []suggestion
+	for _, src := range m.source {
+		if t, ok := byID[src.Id]; (ok && !isEmptyTranslation(t.Translation)) || isEmptyTranslation(src.Translation) {
+			continue
+		}
+		for _, normalize := range []bool{false, true} {
+			index := m.exact
+			if normalize {
+				index = m.normalized
+			}
+			counts := map[string]int{}
+			var candidates []suggestion
+			for _, id := range index[sourceSignature(src.Translation, normalize)] {
+				value, ok := translated(id)
+				if id == src.Id || !ok {
+					continue
+				}
+				raw, err := marshalTranslation(value)
+				if err != nil {
+					return nil, err
+				}
+				if counts[string(raw)] == 0 {
+					candidates = append(candidates, suggestion{
+						Locale:      locale,
+						ID:          src.Id,
+						Source:      src.Translation,
+						Translation: raw,
+						From:        id,
+						Normalized:  normalize,
+					})
+				}
+				counts[string(raw)]++
+			}
+			if len(candidates) == 0 {
+				continue
+			}
+			best := candidates[0]
+			for _, candidate := range candidates[1:] {
+				if counts[string(candidate.Translation)] > counts[string(best.Translation)] {
+					best = candidate
+				}
+			}
+			suggestions = append(suggestions, best)
+			break
+		}
+	}
+	return suggestions, nil
+}
+
+// addSuggestions sets the translations of the suggestions in the items of a
+// locale file, appending the missing keys. Keys translated meanwhile are
+// left untouched. It returns the new items and the number of added
+// translations.
+func addSuggestions(items []Item, suggestions []suggestion) ([]Item, int) {
+	positions := map[string]int{}
+	for i, item := range items {
+		positions[item.ID] = i
+	}
+	added := 0
+	for _, s := range suggestions {
+		i, ok := positions[s.ID]
+		if !ok {
+			positions[s.ID] = len(items)
+			items = append(items, Item{ID: s.ID, Translation: s.Translation})
+			added++
+			continue
+		}
+		var existing interface{}
+		if err := json.Unmarshal(items[i].Translation, &existing); err == nil && !isEmptyTranslation(existing) {
+			continue
+		}
+		items[i].Translation = s.Translation
+		added++
+	}
+	return items, added
+}
+
+// readSuggestions reads reviewed suggestions, grouped by locale. Suggestions
+// whose key is no longer in en.json, or whose source string changed since,
+// are skipped and listed.
+func readSuggestions(filename string, source []Translation) (map[string][]suggestion, []string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	var suggestions []suggestion
+	if err = json.Unmarshal(data, &suggestions); err != nil {
+		return nil, nil, fmt.Errorf("error parsing %s: %v", filename, err)
+	}
+	byID := translationsByID(source)
+	byLocale := map[string][]suggestion{}
+	var skipped []string
+	for _, s := range suggestions {
+		if s.Locale == "" || s.ID == "" {
+			return nil, nil, fmt.Errorf("error parsing %s: suggestion without locale or id", filename)
+		}
+		var value interface{}
+		if err = json.Unmarshal(s.Translation, &value); err != nil || !isValidTranslation(value) {
+			return nil, nil, fmt.Errorf("error parsing %s: invalid translation of %s for %s", filename, s.ID, s.Locale)
+		}
+		src, ok := byID[s.ID]
+		if !ok {
+			skipped = append(skipped, fmt.Sprintf("%s.json: %s is no longer in %s, skipped", s.Locale, s.ID, baseLocaleFile))
+			continue
+		}
+		if !sameTranslation(src.Translation, s.Source) {
+			skipped = append(skipped, fmt.Sprintf("%s.json: source string of %s changed, skipped", s.Locale, s.ID))
+			continue
+		}
+		byLocale[s.Locale] = append(byLocale[s.Locale], s)
+	}
+	return byLocale, skipped, nil
+}
+
+func suggestCmdF(command *cobra.Command, args []string) error {
+	output, err := command.Flags().GetString("output")
+	if err != nil {
+		return errors.New("invalid output parameter")
+	}
+	write, err := command.Flags().GetBool("write")
+	if err != nil {
+		return errors.New("invalid write parameter")
+	}
+	apply, err := command.Flags().GetString("apply")
+	if err != nil {
+		return errors.New("invalid apply parameter")
+	}
+	modes := 0
+	for _, set := range []bool{output != "", write, apply != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return errors.New("please specify only one of output, write or apply")
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	source, err := loadTranslations(path.Join(translationDir, baseLocaleFile))
+	if err != nil {
+		return err
+	}
+	files, err := getLocaleFiles(translationDir)
+	if err != nil {
+		return err
+	}
+	locales := map[string]bool{}
+	for _, file := range files {
+		locales[strings.TrimSuffix(file, ".json")] = true
+	}
+	for _, locale := range args {
+		if !locales[locale] {
+			return fmt.Errorf("no locale file for %s in %s", locale, translationDir)
+		}
+	}
+	selected := func(locale string) bool {
+		if len(args) == 0 {
+			return true
+		}
+		for _, arg := range args {
+			if arg == locale {
+				return true
+			}
+		}
+		return false
+	}
+
+	byLocale := map[string][]suggestion{}
+	if apply != "" {
+		var skipped []string
+		if byLocale, skipped, err = readSuggestions(apply, source); err != nil {
+			return err
+		}
+		for _, s := range skipped {
+			fmt.Println(s)
+		}
+		for locale := range byLocale {
+			if !locales[locale] {
+				return fmt.Errorf("no locale file for %s in %s", locale, translationDir)
+			}
+		}
+	} else {
+		config, err2 := getLocaleConfig(command)
+		if err2 != nil {
+			return err2
+		}
+		checker := newCopyChecker(source, config.EnglishCopies)
+		memory := newTranslationMemory(source)
+		var all []suggestion
+		for _, file := range files {
+			locale := strings.TrimSuffix(file, ".json")
+			if !selected(locale) {
+				continue
+			}
+			translations, err2 := loadTranslations(path.Join(translationDir, file))
+			if err2 != nil {
+				return err2
+			}
+			suggestions, err2 := memory.suggest(locale, translations, checker)
+			if err2 != nil {
+				return err2
+			}
+			byLocale[locale] = suggestions
+			all = append(all, suggestions...)
+		}
+		if output != "" {
+			if all == nil {
+				all = []suggestion{}
+			}
+			data, err2 := JSONMarshal(all)
+			if err2 != nil {
+				return err2
+			}
+			if err2 = writeFileAtomically(output, data, 0644); err2 != nil {
+				return err2
+			}
+			fmt.Printf("%s: %d translations suggested\n", output, len(all))
+			return nil
+		}
+		if !write {
+			for _, s := range all {
+				fmt.Println(s)
+			}
+			return nil
+		}
+	}
+
+	for _, file := range files {
+		locale := strings.TrimSuffix(file, ".json")
+		if len(byLocale[locale]) == 0 || !selected(locale) {
+			continue
+		}
+		filename := path.Join(translationDir, file)
+		items, err2 := loadItems(filename)
+		if err2 != nil {
+			return err2
+		}
+		items, added := addSuggestions(items, byLocale[locale])
+		if added == 0 {
+			continue
+		}
+		if err2 = writeLocaleFile(filename, items); err2 != nil {
+			return err2
+		}
+		fmt.Printf("%s: %d translations updated\n", filename, added)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSource(t *testing.T) {
+	assert.Equal(t, "save your changes", normalizeSource("  Save  your\nChanges "))
+	assert.Equal(t, "hello {{.Name}}", normalizeSource("Hello {{.Name}}"))
+}
+
+func TestTranslationMemorySuggest(t *testing.T) {
+	var source, translations []Translation
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"id": "a.cancel", "translation": "Cancel"},
+		{"id": "b.cancel", "translation": "Cancel"},
+		{"id": "c.cancel", "translation": "Cancel"},
+		{"id": "d.cancel", "translation": "Cancel"},
+		{"id": "a.save", "translation": "Save changes"},
+		{"id": "b.save", "translation": "Save  Changes"},
+		{"id": "a.files", "translation": {"one": "{{.Count}} file", "other": "{{.Count}} files"}},
+		{"id": "b.files", "translation": {"one": "{{.Count}} file", "other": "{{.Count}} files"}},
+		{"id": "a.copied", "translation": "Delete"},
+		{"id": "b.copied", "translation": "Delete"},
+		{"id": "a.empty", "translation": ""},
+		{"id": "b.empty", "translation": ""}
+	]`), &source))
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"id": "a.cancel", "translation": "Annuler"},
+		{"id": "b.cancel", "translation": "Abandonner"},
+		{"id": "c.cancel", "translation": "Abandonner"},
+		{"id": "b.save", "translation": "Enregistrer les modifications"},
+		{"id": "a.files", "translation": {"one": "{{.Count}} fichier", "other": "{{.Count}} fichiers"}},
+		{"id": "b.files", "translation": {"one": "", "other": ""}},
+		{"id": "a.copied", "translation": "Delete"},
+		{"id": "a.empty", "translation": "Vide"}
+	]`), &translations))
+
+	memory := newTranslationMemory(source)
+	suggestions, err := memory.suggest("fr", translations, newCopyChecker(source, englishCopiesConfig{}))
+	require.NoError(t, err)
+	assert.Equal(t, []suggestion{
+		{Locale: "fr", ID: "d.cancel", Source: "Cancel", Translation: json.RawMessage(`"Abandonner"`), From: "b.cancel"},
+		{Locale: "fr", ID: "a.save", Source: "Save changes", Translation: json.RawMessage(`"Enregistrer les modifications"`), From: "b.save", Normalized: true},
+		{
+			Locale:      "fr",
+			ID:          "b.files",
+			Source:      map[string]interface{}{"one": "{{.Count}} file", "other": "{{.Count}} files"},
+			Translation: json.RawMessage(`{"one":"{{.Count}} fichier","other":"{{.Count}} fichiers"}`),
+			From:        "a.files",
+		},
+	}, suggestions)
+}
+
+func TestAddSuggestions(t *testing.T) {
+	items := []Item{
+		{ID: "a.empty", Translation: json.RawMessage(`""`)},
+		{ID: "a.translated", Translation: json.RawMessage(`"Traduit"`)},
+	}
+	items, added := addSuggestions(items, []suggestion{
+		{ID: "a.empty", Translation: json.RawMessage(`"Vide"`)},
+		{ID: "a.translated", Translation: json.RawMessage(`"Autre"`)},
+		{ID: "a.missing", Translation: json.RawMessage(`"Manquant"`)},
+	})
+	assert.Equal(t, 2, added)
+	assert.Equal(t, []Item{
+		{ID: "a.empty", Translation: json.RawMessage(`"Vide"`)},
+		{ID: "a.translated", Translation: json.RawMessage(`"Traduit"`)},
+		{ID: "a.missing", Translation: json.RawMessage(`"Manquant"`)},
+	}, items)
+}
+
+func TestReadSuggestions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mmgotool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	source := []Translation{{Id: "a.save", Translation: "Save"}, {Id: "a.cancel", Translation: "Cancel"}}
+
+	filename := filepath.Join(dir, "suggestions.json")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(`[
+		{"locale": "fr", "id": "a.save", "source": "Save", "translation": "Enregistrer", "from": "b.save"},
+		{"locale": "de", "id": "a.save", "source": "Save", "translation": "Speichern", "from": "b.save"},
+		{"locale": "fr", "id": "a.cancel", "source": "Cancel it", "translation": "Annuler", "from": "b.cancel"},
+		{"locale": "fr", "id": "a.removed", "source": "Removed", "translation": "Supprimé", "from": "b.removed"}
+	]`), 0600))
+	byLocale, skipped, err := readSuggestions(filename, source)
+	require.NoError(t, err)
+	assert.Len(t, byLocale["fr"], 1)
+	assert.Len(t, byLocale["de"], 1)
+	assert.Equal(t, []string{
+		"fr.json: source string of a.cancel changed, skipped",
+		"fr.json: a.removed is no longer in en.json, skipped",
+	}, skipped)
+
+	require.NoError(t, ioutil.WriteFile(filename, []byte(`[{"locale": "fr", "id": "a.save", "source": "Save", "translation": 1}]`), 0600))
+	_, _, err = readSuggestions(filename, source)
+	assert.Error(t, err)
+}
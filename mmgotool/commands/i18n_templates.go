@@ -0,0 +1,157 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// maxSnippetLength is the number of characters of the offending line kept in
+// the reported snippet.
+const maxSnippetLength = 80
+
+var CheckTemplatesCmd = &cobra.Command{
+	Use:     "check-templates",
+	Short:   "Check translation templates syntax",
+	Long:    "Check that every translation of every locale file, plural forms included, parses as a Go template",
+	Example: "  i18n check-templates",
+	RunE:    checkTemplatesCmdF,
+}
+
+// templateErrorRegexp matches the "template: name:line: " prefix of the
+// text/template parse errors.
+var templateErrorRegexp = regexp.MustCompile(`^template: .*?:(\d+): `)
+
+func init() {
+	addLocaleDirFlags(CheckTemplatesCmd)
+	addFormatFlag(CheckTemplatesCmd)
+
+	I18nCmd.AddCommand(CheckTemplatesCmd)
+}
+
+// templateError is a translation that does not parse as a Go template.
+type templateError struct {
+	File    string `json:"file"`
+	ID      string `json:"id"`
+	Form    string `json:"form,omitempty"`
+	Error   string `json:"error"`
+	Snippet string `json:"snippet"`
+}
+
+func (e templateError) String() string {
+	id := e.ID
+	if e.Form != "" {
+		id += " (" + e.Form + ")"
+	}
+	return fmt.Sprintf("%s: %s: %s: %q", e.File, id, e.Error, e.Snippet)
+}
+
+func (e templateError) message() string {
+	message := fmt.Sprintf("%s: %q", e.Error, e.Snippet)
+	if e.Form != "" {
+		message = "plural form " + e.Form + ": " + message
+	}
+	return message
+}
+
+// parseTemplate parses text the way the server translation bundle does and
+// returns the error message and the offending line.
+func parseTemplate(text string) (string, string, bool) {
+	if !strings.Contains(text, "{{") {
+		return "", "", true
+	}
+	_, err := template.New("translation").Parse(text)
+	if err == nil {
+		return "", "", true
+	}
+
+	message := err.Error()
+	lines := strings.Split(text, "\n")
+	snippet := lines[0]
+	if match := templateErrorRegexp.FindStringSubmatch(message); match != nil {
+		message = strings.TrimPrefix(message, match[0])
+		if line, convErr := strconv.Atoi(match[1]); convErr == nil && line >= 1 && line <= len(lines) {
+			snippet = lines[line-1]
+		}
+	}
+	// Cut on a character boundary, most locales are not ASCII.
+	if runes := []rune(snippet); len(runes) > maxSnippetLength {
+		snippet = string(runes[:maxSnippetLength]) + "..."
+	}
+	return message, snippet, false
+}
+
+func checkTemplates(file string, translations []Translation) []templateError {
+	var errs []templateError
+	for _, t := range translations {
+		forms := translationForms(t.Translation)
+		var names []string
+		for form := range forms {
+			names = append(names, form)
+		}
+		sort.Strings(names)
+		for _, form := range names {
+			message, snippet, ok := parseTemplate(forms[form])
+			if ok {
+				continue
+			}
+			errs = append(errs, templateError{File: file, ID: t.Id, Form: form, Error: message, Snippet: snippet})
+		}
+	}
+	return errs
+}
+
+func checkTemplatesCmdF(command *cobra.Command, args []string) error {
+	r, err := newReporter(command)
+	if err != nil {
+		return err
+	}
+	translationDir, err := getLocaleDir(command)
+	if err != nil {
+		return err
+	}
+	files, err := getLocaleFiles(translationDir)
+	if err != nil {
+		return err
+	}
+	files = append([]string{baseLocaleFile}, files...)
+
+	var errs []templateError
+	for _, file := range files {
+		translations, err2 := loadTranslations(path.Join(translationDir, file))
+		if err2 != nil {
+			return err2
+		}
+		errs = append(errs, checkTemplates(file, translations)...)
+	}
+
+	for _, e := range errs {
+		filename := path.Join(translationDir, e.File)
+		r.report(e.String(), finding{
+			RuleID:   "invalid-template",
+			Severity: severityError,
+			Key:      e.ID,
+			File:     filename,
+			Line:     r.keyLine(filename, e.ID),
+			Message:  e.message(),
+		})
+	}
+	if err = r.flush(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		command.SilenceUsage = true
+		return errors.New("invalid translation templates found")
+	}
+	return nil
+}
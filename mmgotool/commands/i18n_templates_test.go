@@ -0,0 +1,46 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTemplates(t *testing.T) {
+	translations := []Translation{
+		{Id: "valid", Translation: "Hello {{.Name}}"},
+		{Id: "plain", Translation: "No template at all }}"},
+		{Id: "unclosed", Translation: "{{.Count} items"},
+		{Id: "function", Translation: "{{ .Name | bogus }}"},
+		{Id: "plural", Translation: map[string]interface{}{"one": "One", "other": "Line one\n{{.Count items"}},
+	}
+
+	errs := checkTemplates("fr.json", translations)
+	require.Len(t, errs, 3)
+
+	assert.Equal(t, "unclosed", errs[0].ID)
+	assert.Equal(t, "{{.Count} items", errs[0].Snippet)
+	assert.Contains(t, errs[0].Error, `'}'`)
+
+	assert.Equal(t, "function", errs[1].ID)
+	assert.Contains(t, errs[1].Error, `function "bogus" not defined`)
+
+	assert.Equal(t, "plural", errs[2].ID)
+	assert.Equal(t, "other", errs[2].Form)
+	assert.Equal(t, "{{.Count items", errs[2].Snippet)
+	assert.Equal(t, `fr.json: plural (other): `+errs[2].Error+`: "{{.Count items"`, errs[2].String())
+}
+
+func TestParseTemplateSnippet(t *testing.T) {
+	line := strings.Repeat("é", maxSnippetLength+10) + "{{.Count"
+	_, snippet, ok := parseTemplate(line)
+	require.False(t, ok)
+	assert.True(t, utf8.ValidString(snippet))
+	assert.Equal(t, strings.Repeat("é", maxSnippetLength)+"...", snippet)
+}
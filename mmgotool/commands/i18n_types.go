@@ -0,0 +1,250 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// typedLoadMode type checks the dependencies from source as well, so loading
+// does not depend on the export data format of the installed Go toolchain.
+const typedLoadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax |
+	packages.NeedTypesInfo | packages.NeedModule | packages.NeedDeps
+
+// rejectedCall is a call matching a translation function by name whose callee
+// does not resolve to a translation function.
+type rejectedCall struct {
+	keyLocation
+	Func   string `json:"func"`
+	Reason string `json:"reason"`
+}
+
+func (c rejectedCall) String() string {
+	return fmt.Sprintf("%s: %s (%s)", c.keyLocation, c.Func, c.Reason)
+}
+
+// extractTypedSrcStrings loads the scanned modules with go/packages and only
+// accepts the calls whose callee resolves to a registered translation
+// function. Keys are taken from the constant value computed by the type
+// checker, so any constant expression is supported.
+func extractTypedSrcStrings(opts *extractOptions) (*extractResult, error) {
+	var pkgs []*packages.Package
+	var rules []*ignoreRules
+	for _, dir := range opts.sourceDirs() {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		dirRules, err := loadIgnoreRules(absDir)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, dirRules)
+
+		cfg := &packages.Config{Mode: typedLoadMode, Dir: dir}
+		if len(opts.BuildTags) > 0 {
+			cfg.BuildFlags = []string{"-tags=" + strings.Join(opts.BuildTags, ",")}
+		}
+		loaded, err := packages.Load(cfg, "./...")
+		if err != nil {
+			return nil, fmt.Errorf("error loading packages in %s: %v", dir, err)
+		}
+		pkgs = append(pkgs, loaded...)
+	}
+	isIgnored := func(filename string) bool {
+		for _, r := range rules {
+			if r.contains(filename) && r.ignored(filename, false) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Packages with errors still carry the syntax of the files that parsed,
+	// so extraction goes on and the errors are reported at the end.
+	var errs extractErrors
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, pkgErr := range pkg.Errors {
+			file := pkg.PkgPath
+			if pkgErr.Pos != "" && pkgErr.Pos != "-" {
+				file = pkgErr.Pos
+			}
+			errs = append(errs, fileError{File: file, Message: pkgErr.Msg})
+		}
+	})
+
+	var modules []string
+	for _, pkg := range pkgs {
+		if pkg.Module != nil {
+			modules = append(modules, pkg.Module.Path)
+		}
+	}
+	isLocal := func(pkgPath string) bool {
+		for _, module := range modules {
+			if pkgPath == module || strings.HasPrefix(pkgPath, module+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	registry := newFuncRegistry(opts.Config.Functions)
+	result := &extractResult{Usages: i18nUsages{}}
+	seen := map[string]bool{}
+	for _, pkg := range pkgs {
+		if seen[pkg.ID] {
+			continue
+		}
+		seen[pkg.ID] = true
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, f := range pkg.Syntax {
+			filename := pkg.Fset.Position(f.Pos()).Filename
+			if isIgnored(filename) || ast.IsGenerated(f) {
+				continue
+			}
+			imports := fileImports(f)
+			annotated := annotatedLines(pkg.Fset, f)
+			ast.Inspect(f, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				pos := pkg.Fset.Position(call.Pos())
+				fn, reason := registry.typedMatch(call, pkg.TypesInfo, isLocal)
+				if fn == nil {
+					if reason != "" && registry.match(call, imports) != nil {
+						result.RejectedCalls = append(result.RejectedCalls, rejectedCall{
+							keyLocation: keyLocation{File: pos.Filename, Line: pos.Line},
+							Func:        types.ExprString(call.Fun),
+							Reason:      reason,
+						})
+					}
+					return true
+				}
+				result.addCall(call, fn, pos, pkg.Name, annotated.covers(pos.Line), func(expr ast.Expr) (string, bool) {
+					tv, ok := pkg.TypesInfo.Types[expr]
+					if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+						return "", false
+					}
+					return constant.StringVal(tv.Value), true
+				})
+				return true
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return result, errs
+	}
+	return result, nil
+}
+
+// typedMatch is the type-aware counterpart of match. It returns the
+// registered function the callee resolves to, otherwise the reason why the
+// candidate call was rejected. Functions without
+// a package qualifier must be declared in one of the scanned modules.
+func (r *funcRegistry) typedMatch(call *ast.CallExpr, info *types.Info, isLocal func(string) bool) (*translationFunc, string) {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	case *ast.Ident:
+		ident = fun
+	default:
+		return nil, ""
+	}
+	candidates := r.byName[ident.Name]
+	if len(candidates) == 0 {
+		return nil, ""
+	}
+
+	obj := info.Uses[ident]
+	if obj == nil || obj.Pkg() == nil {
+		return nil, "unresolved callee"
+	}
+	sig, ok := obj.Type().Underlying().(*types.Signature)
+	if !ok {
+		return nil, "not a function"
+	}
+
+	pkgPath := obj.Pkg().Path()
+	if v, ok := obj.(*types.Var); ok {
+		// Variables of a named function type, like i18n.TranslateFunc, belong
+		// to the package declaring the type.
+		if named, ok := v.Type().(*types.Named); ok && named.Obj().Pkg() != nil {
+			pkgPath = named.Obj().Pkg().Path()
+		}
+	}
+	var receiver string
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		if selection, ok := info.Selections[sel]; ok {
+			receiver = typeName(selection.Recv())
+		}
+	}
+
+	reason := ""
+	for i := range candidates {
+		f := &candidates[i]
+		if f.Package != "" && pkgPath != f.Package {
+			reason = "declared in " + pkgPath
+			continue
+		}
+		if f.Package == "" && !isLocal(pkgPath) {
+			reason = "declared outside the scanned modules in " + pkgPath
+			continue
+		}
+		if f.Receiver != "" && receiver != strings.TrimPrefix(f.Receiver, "*") {
+			reason = "receiver is not " + f.Receiver
+			continue
+		}
+		if !isStringParam(sig, f.KeyArg) {
+			reason = fmt.Sprintf("argument %d is not a string", f.KeyArg)
+			continue
+		}
+		if len(call.Args) <= f.KeyArg {
+			continue
+		}
+		return f, ""
+	}
+	return nil, reason
+}
+
+func typeName(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return path.Base(t.String())
+}
+
+func isStringParam(sig *types.Signature, i int) bool {
+	params := sig.Params()
+	var t types.Type
+	switch {
+	case sig.Variadic() && i >= params.Len()-1:
+		t = params.At(params.Len() - 1).Type().(*types.Slice).Elem()
+	case i < params.Len():
+		t = params.At(i).Type()
+	default:
+		return false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractTypedSrcStrings(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"server/go.mod": `module example.com/server
+
+go 1.14
+
+require example.com/other v0.0.0
+
+replace example.com/other => ../other
+`,
+		"server/i18n/i18n.go": `package i18n
+
+type TranslateFunc func(id string, args ...interface{}) string
+
+var T TranslateFunc = func(id string, args ...interface{}) string { return id }
+`,
+		"server/model/errors.go": `package model
+
+const MissingChannelError = "model.channel.missing.app_error"
+
+type AppError struct{}
+
+func NewAppError(where, id string, params map[string]interface{}, details string, status int) *AppError {
+	return &AppError{}
+}
+`,
+		"server/app/app.go": `package app
+
+import (
+	translations "example.com/server/i18n"
+	"example.com/server/model"
+	"example.com/other"
+)
+
+const prefix = "app.channel."
+
+func f(o *other.Other, key string) {
+	translations.T("app.aliased")
+	model.NewAppError("f", model.MissingChannelError, nil, "", 0)
+	translations.T(prefix + "create.app_error")
+	o.T("other.not_a_key")
+	translations.T(key)
+}
+`,
+		"other/go.mod": "module example.com/other\n\ngo 1.14\n",
+		"other/other.go": `package other
+
+type Other struct{}
+
+func (o *Other) T(id string) string { return id }
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	serverDir := filepath.Join(dir, "server")
+	opts := &extractOptions{MattermostDir: serverDir, TranslationDir: serverDir, SkipDynamic: true, TypeAware: true, Config: defaultI18nConfig()}
+	result, err := extractSrcStrings(opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"app.aliased",
+		"app.channel.create.app_error",
+		"model.channel.missing.app_error",
+	}, result.Usages.keys())
+
+	require.Len(t, result.RejectedCalls, 1)
+	assert.Equal(t, "o.T", result.RejectedCalls[0].Func)
+	assert.Equal(t, 15, result.RejectedCalls[0].Line)
+	assert.Contains(t, result.RejectedCalls[0].Reason, "example.com/other")
+
+	require.Len(t, result.DynamicCalls, 1)
+	assert.Equal(t, "key", result.DynamicCalls[0].Key)
+}
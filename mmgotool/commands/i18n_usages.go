@@ -0,0 +1,114 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// keyLocation is a position in the source code where a translation key is
+// used.
+type keyLocation struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+func (l keyLocation) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// i18nUsages maps every extracted translation key to the locations where it
+// is used.
+type i18nUsages map[string][]keyLocation
+
+func (u i18nUsages) add(id string, pos token.Position) {
+	u[id] = append(u[id], keyLocation{File: pos.Filename, Line: pos.Line})
+}
+
+func (u i18nUsages) keys() []string {
+	var keys []string
+	for id := range u {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var UsagesCmd = &cobra.Command{
+	Use:     "usages [keys]",
+	Short:   "List translation key usages",
+	Long:    "List every translation key found in the source code with the file and line of each usage",
+	Example: "  i18n usages\n  i18n usages api.context.404.app_error --format json",
+	RunE:    usagesCmdF,
+}
+
+func init() {
+	addExtractFlags(UsagesCmd)
+	UsagesCmd.Flags().String("format", "text", "Output format, one of: text, json")
+
+	I18nCmd.AddCommand(UsagesCmd)
+}
+
+func usagesCmdF(command *cobra.Command, args []string) error {
+	opts, err := getExtractOptions(command)
+	if err != nil {
+		return err
+	}
+	format, err := command.Flags().GetString("format")
+	if err != nil {
+		return errors.New("invalid format parameter")
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format %q, must be one of: text, json", format)
+	}
+
+	var baseFileList []string
+	if !opts.SkipDynamic {
+		srcStrings, err2 := getBaseFileSrcStrings(opts.TranslationDir)
+		if err2 != nil {
+			return err2
+		}
+		for _, t := range srcStrings {
+			baseFileList = append(baseFileList, t.Id)
+		}
+	}
+
+	extracted, _, err := extractUsages(opts, baseFileList)
+	if err != nil {
+		command.SilenceUsage = true
+		return err
+	}
+	usages := extracted.Usages
+	if len(args) > 0 {
+		filtered := i18nUsages{}
+		for _, id := range args {
+			if locations, ok := usages[id]; ok {
+				filtered[id] = locations
+			}
+		}
+		usages = filtered
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(usages)
+	}
+
+	for _, id := range usages.keys() {
+		fmt.Println(id)
+		for _, location := range usages[id] {
+			fmt.Println("\t" + location.String())
+		}
+	}
+	return nil
+}
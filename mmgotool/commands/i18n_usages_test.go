@@ -0,0 +1,66 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSourceTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "mmgotool")
+	require.NoError(t, err)
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0700))
+		require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0600))
+	}
+	return dir
+}
+
+func TestExtractSrcStringsLocations(t *testing.T) {
+	dir := writeSourceTree(t, map[string]string{
+		"app/a.go": `package app
+
+func f() {
+	c.T("app.first")
+	model.NewAppError("f", "app.second", nil, "", 0)
+	c.T("app.first")
+}
+`,
+		"app/b.go": `package app
+
+func g() {
+	c.T("app.first")
+}
+`,
+		"app/a_test.go": `package app
+
+func h() {
+	c.T("app.test_only")
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	opts := &extractOptions{MattermostDir: dir, TranslationDir: dir, SkipDynamic: true, Config: defaultI18nConfig()}
+	result, err := extractSrcStrings(opts)
+	require.NoError(t, err)
+	usages := result.Usages
+
+	assert.Equal(t, []string{"app.first", "app.second"}, usages.keys())
+	assert.Equal(t, []keyLocation{
+		{File: filepath.Join(dir, "app/a.go"), Line: 4},
+		{File: filepath.Join(dir, "app/a.go"), Line: 6},
+		{File: filepath.Join(dir, "app/b.go"), Line: 4},
+	}, usages["app.first"])
+	assert.Equal(t, []keyLocation{
+		{File: filepath.Join(dir, "app/a.go"), Line: 5},
+	}, usages["app.second"])
+}
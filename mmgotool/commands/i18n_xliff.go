@@ -0,0 +1,288 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	xliff12Namespace = "urn:oasis:names:tc:xliff:document:1.2"
+	xliff20Namespace = "urn:oasis:names:tc:xliff:document:2.0"
+	// xliffPluralRestype marks the XLIFF 1.2 groups holding the plural forms
+	// of a translation, one trans-unit per plural category.
+	xliffPluralRestype = "x-gettext-plurals"
+	xmlSpacePreserve   = "preserve"
+)
+
+type xliffText struct {
+	Text string `xml:",chardata"`
+}
+
+func newXLIFFText(forms map[string]string, form string) *xliffText {
+	text, ok := forms[form]
+	if !ok || text == "" {
+		return nil
+	}
+	return &xliffText{Text: text}
+}
+
+type xliff12 struct {
+	XMLName xml.Name      `xml:"xliff"`
+	Xmlns   string        `xml:"xmlns,attr,omitempty"`
+	Version string        `xml:"version,attr"`
+	Files   []xliff12File `xml:"file"`
+}
+
+type xliff12File struct {
+	Original       string      `xml:"original,attr"`
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr,omitempty"`
+	Datatype       string      `xml:"datatype,attr"`
+	Body           xliff12Body `xml:"body"`
+}
+
+type xliff12Body struct {
+	Nodes []xliff12Node `xml:",any"`
+}
+
+// xliff12Node is a trans-unit or a group of the body of an XLIFF 1.2 file.
+type xliff12Node struct {
+	XMLName xml.Name
+	ID      string        `xml:"id,attr"`
+	Restype string        `xml:"restype,attr,omitempty"`
+	Space   string        `xml:"http://www.w3.org/XML/1998/namespace space,attr,omitempty"`
+	Source  *xliffText    `xml:"source"`
+	Target  *xliffText    `xml:"target"`
+	Nodes   []xliff12Node `xml:",any"`
+}
+
+func writeXLIFF12(w io.Writer, file *exchangeFile) error {
+	doc := xliff12{
+		Xmlns:   xliff12Namespace,
+		Version: "1.2",
+		Files: []xliff12File{{
+			Original:       baseLocaleFile,
+			SourceLanguage: "en",
+			TargetLanguage: file.Locale,
+			Datatype:       "plaintext",
+		}},
+	}
+	for _, unit := range file.Units {
+		if !unit.Plural {
+			doc.Files[0].Body.Nodes = append(doc.Files[0].Body.Nodes, xliff12Node{
+				XMLName: xml.Name{Local: "trans-unit"},
+				ID:      unit.ID,
+				Space:   xmlSpacePreserve,
+				Source:  &xliffText{Text: unit.Source[""]},
+				Target:  newXLIFFText(unit.Target, ""),
+			})
+			continue
+		}
+		group := xliff12Node{XMLName: xml.Name{Local: "group"}, ID: unit.ID, Restype: xliffPluralRestype}
+		for _, category := range file.Categories {
+			group.Nodes = append(group.Nodes, xliff12Node{
+				XMLName: xml.Name{Local: "trans-unit"},
+				ID:      unit.ID + "[" + category + "]",
+				Space:   xmlSpacePreserve,
+				Source:  &xliffText{Text: unit.pluralSource(category)},
+				Target:  newXLIFFText(unit.Target, category),
+			})
+		}
+		doc.Files[0].Body.Nodes = append(doc.Files[0].Body.Nodes, group)
+	}
+	return writeXML(w, doc)
+}
+
+func readXLIFF12(data []byte) (*exchangeFile, error) {
+	var doc xliff12
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	file := &exchangeFile{}
+	for _, f := range doc.Files {
+		if file.Locale == "" {
+			file.Locale = f.TargetLanguage
+		}
+		units, err := xliff12Units(f.Body.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		file.Units = append(file.Units, units...)
+	}
+	return file, nil
+}
+
+func xliff12Units(nodes []xliff12Node) ([]exchangeUnit, error) {
+	var units []exchangeUnit
+	for _, node := range nodes {
+		switch node.XMLName.Local {
+		case "trans-unit":
+			unit := exchangeUnit{ID: node.ID, Source: map[string]string{}, Target: map[string]string{}}
+			if node.Source != nil {
+				unit.Source[""] = node.Source.Text
+			}
+			if node.Target != nil {
+				unit.Target[""] = node.Target.Text
+				unit.Cleared = node.Target.Text == ""
+			}
+			units = append(units, unit)
+		case "group":
+			if node.Restype != xliffPluralRestype {
+				groupUnits, err := xliff12Units(node.Nodes)
+				if err != nil {
+					return nil, err
+				}
+				units = append(units, groupUnits...)
+				continue
+			}
+			unit := exchangeUnit{ID: node.ID, Plural: true, Source: map[string]string{}, Target: map[string]string{}}
+			for _, form := range node.Nodes {
+				category := strings.TrimSuffix(strings.TrimPrefix(form.ID, node.ID+"["), "]")
+				if !isPluralCategory(category) {
+					return nil, fmt.Errorf("%s: unknown plural category %q", node.ID, category)
+				}
+				if form.Source != nil {
+					unit.Source[category] = form.Source.Text
+				}
+				if form.Target != nil {
+					unit.Target[category] = form.Target.Text
+				}
+			}
+			unit.Cleared = len(unit.Target) > 0 && isEmptyTranslation(unit.value())
+			units = append(units, unit)
+		}
+	}
+	return units, nil
+}
+
+type xliff20 struct {
+	XMLName xml.Name      `xml:"xliff"`
+	Xmlns   string        `xml:"xmlns,attr,omitempty"`
+	Version string        `xml:"version,attr"`
+	SrcLang string        `xml:"srcLang,attr"`
+	TrgLang string        `xml:"trgLang,attr,omitempty"`
+	Files   []xliff20File `xml:"file"`
+}
+
+type xliff20File struct {
+	ID       string        `xml:"id,attr"`
+	Original string        `xml:"original,attr,omitempty"`
+	Space    string        `xml:"http://www.w3.org/XML/1998/namespace space,attr,omitempty"`
+	Units    []xliff20Unit `xml:"unit"`
+}
+
+// xliff20Unit is a translation. Plural translations hold a segment per
+// plural category, identified by the category.
+type xliff20Unit struct {
+	ID       string           `xml:"id,attr"`
+	Segments []xliff20Segment `xml:"segment"`
+}
+
+type xliff20Segment struct {
+	ID     string     `xml:"id,attr,omitempty"`
+	Source xliffText  `xml:"source"`
+	Target *xliffText `xml:"target"`
+}
+
+func writeXLIFF20(w io.Writer, file *exchangeFile) error {
+	doc := xliff20{
+		Xmlns:   xliff20Namespace,
+		Version: "2.0",
+		SrcLang: "en",
+		TrgLang: file.Locale,
+		Files:   []xliff20File{{ID: "f1", Original: baseLocaleFile, Space: xmlSpacePreserve}},
+	}
+	for _, unit := range file.Units {
+		u := xliff20Unit{ID: unit.ID}
+		if !unit.Plural {
+			u.Segments = []xliff20Segment{{Source: xliffText{Text: unit.Source[""]}, Target: newXLIFFText(unit.Target, "")}}
+			doc.Files[0].Units = append(doc.Files[0].Units, u)
+			continue
+		}
+		for _, category := range file.Categories {
+			u.Segments = append(u.Segments, xliff20Segment{
+				ID:     category,
+				Source: xliffText{Text: unit.pluralSource(category)},
+				Target: newXLIFFText(unit.Target, category),
+			})
+		}
+		doc.Files[0].Units = append(doc.Files[0].Units, u)
+	}
+	return writeXML(w, doc)
+}
+
+// readXLIFF20 reads an XLIFF 2.0 file. Units are plural when their source
+// string is, their segments are then identified by plural category. Tools
+// split the other units into sentences, their segments are joined in order.
+func readXLIFF20(data []byte, source map[string]Translation) (*exchangeFile, error) {
+	var doc xliff20
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	file := &exchangeFile{Locale: doc.TrgLang}
+	for _, f := range doc.Files {
+		for _, u := range f.Units {
+			unit := exchangeUnit{ID: u.ID, Source: map[string]string{}, Target: map[string]string{}}
+			_, unit.Plural = source[u.ID].Translation.(map[string]interface{})
+			for _, segment := range u.Segments {
+				form := ""
+				if unit.Plural {
+					if !isPluralCategory(segment.ID) {
+						return nil, fmt.Errorf("%s: unknown plural category %q", u.ID, segment.ID)
+					}
+					form = segment.ID
+				}
+				unit.Source[form] += segment.Source.Text
+				if segment.Target != nil {
+					unit.Target[form] += segment.Target.Text
+				}
+			}
+			unit.Cleared = len(unit.Target) > 0 && isEmptyTranslation(unit.value())
+			file.Units = append(file.Units, unit)
+		}
+	}
+	return file, nil
+}
+
+// xliffVersion returns the version attribute of the root element of an
+// XLIFF document.
+func xliffVersion(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local != "xliff" {
+				return "", errors.New("not an XLIFF document")
+			}
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "version" {
+					return attr.Value, nil
+				}
+			}
+			return "", errors.New("XLIFF document without version")
+		}
+	}
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -1,5 +1,19 @@
 module github.com/mattermost/mattermost-utilities/mmgotool
 
-go 1.14
+go 1.22.0
 
-require github.com/spf13/cobra v1.0.0
+require (
+	github.com/pmezard/go-difflib v1.0.0
+	github.com/spf13/cobra v1.0.0
+	github.com/stretchr/testify v1.7.0
+	golang.org/x/tools v0.26.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/spf13/pflag v1.0.3 // indirect
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)